@@ -0,0 +1,22 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// newExplainCmd documents the explain prefix. An invocation prefixed by
+// explain is rewritten by CreateRootCmd, so this command is only reached
+// when nothing follows explain.
+func newExplainCmd(withoutRunSubcmd bool) *cobra.Command {
+	use := "explain run [handle] [args...]"
+	if withoutRunSubcmd {
+		use = "explain [handle] [args...]"
+	}
+	return &cobra.Command{
+		Use:   use,
+		Short: "Explain how a handle command line is built, without executing it",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+}
@@ -21,6 +21,7 @@
 //	      --json-file string   json file to use to render template, with '-' for stdin
 //	  -o, --out string         destination directory, or '-' for stdout (default ".summoned")
 //	      --raw                output without any template rendering
+//	      --strict             fail on missing keys in templates
 //	  -v, --version            output data version info and exit
 //
 //	Use "summon [command] --help" for more information about a command.
@@ -58,6 +59,12 @@ func CreateRootCmd(driver *summon.Driver, args []string, options summon.MainOpti
 	exeName := filepath.Base(args[0])
 	var showVersion bool
 
+	// explain traces the invocation that follows it instead of running it
+	if len(args) > 2 && args[1] == "explain" {
+		args = append([]string{args[0]}, args[2:]...)
+		driver.Configure(summon.Explain(true))
+	}
+
 	main := &mainCmd{
 		driver: driver,
 		osArgs: &args,
@@ -138,14 +145,30 @@ func CreateRootCmd(driver *summon.Driver, args []string, options summon.MainOpti
 	}
 
 	// add run cmd, or root subcommands
+	commandsBeforeRun := len(rootCmd.Commands())
 	runRoot, err := newRunCmd(!options.WithoutRunSubcmd, rootCmd, driver, main)
 	if err != nil {
 		return nil, err
 	}
+	hasHandles := len(runRoot.Commands()) != 0
+	if runRoot == rootCmd {
+		hasHandles = len(rootCmd.Commands()) > commandsBeforeRun
+	}
 
 	// add completion
 	rootCmd.AddCommand(newCompletionCmd(driver))
 
+	// add explain if there are handles to explain, it is handled before the
+	// command tree is parsed
+	if hasHandles {
+		rootCmd.AddCommand(newExplainCmd(options.WithoutRunSubcmd))
+	}
+
+	// add go tools management if handles use go tools
+	if tools, _ := driver.Tools(); len(tools) != 0 {
+		rootCmd.AddCommand(newToolsCmd(driver))
+	}
+
 	// ask driver to register its flags
 	driver.RegisterFlags(runRoot)
 
@@ -55,6 +55,21 @@ func TestRunCmd(t *testing.T) {
 			args:    []string{"run", "-n", "echo", "hello"},
 			noCalls: true,
 		},
+		{
+			desc:    "dry-run-json",
+			args:    []string{"run", "--dry-run=json", "echo", "hello"},
+			noCalls: true,
+		},
+		{
+			desc:      "dry-run-unknown-format",
+			args:      []string{"run", "--dry-run=yaml", "echo", "hello"},
+			wantError: true,
+		},
+		{
+			desc:    "explain",
+			args:    []string{"explain", "run", "echo", "hello"},
+			noCalls: true,
+		},
 		{
 			desc: "run-completion",
 			args: []string{"__complete", "run", "tk", ""},
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/davidovich/summon/pkg/summon"
+)
+
+type toolsCmdOpts struct {
+	driver summon.ToolManager
+	all    bool
+	out    io.Writer
+}
+
+func newToolsCmd(driver summon.ToolManager) *cobra.Command {
+	tOpts := &toolsCmdOpts{
+		driver: driver,
+	}
+
+	tools := &cobra.Command{
+		Use:   "tools",
+		Short: "Manage the pinned go tools of handles",
+	}
+
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "List the pinned go tools and their cache status",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tOpts.out = cmd.OutOrStdout()
+			return tOpts.list()
+		},
+	}
+
+	prune := &cobra.Command{
+		Use:   "prune",
+		Short: "Remove cached go tools that are no longer used by handles",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tOpts.out = cmd.OutOrStdout()
+			return tOpts.prune()
+		},
+	}
+	prune.Flags().BoolVar(&tOpts.all, "all", false, "remove all cached go tools")
+
+	tools.AddCommand(list, prune)
+
+	return tools
+}
+
+func (t *toolsCmdOpts) list() error {
+	tools, err := t.driver.Tools()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(t.out, 0, 4, 2, ' ', 0)
+	for _, tool := range tools {
+		status := "not installed"
+		if tool.Installed {
+			status = "installed"
+		}
+		fmt.Fprintf(w, "%s\t%s@%s\t%s\t%s\n", tool.Handle, tool.Module, tool.Version, status, tool.Path)
+	}
+	return w.Flush()
+}
+
+func (t *toolsCmdOpts) prune() error {
+	pruned, err := t.driver.PruneTools(t.all)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range pruned {
+		fmt.Fprintln(t.out, "removed", p)
+	}
+	return nil
+}
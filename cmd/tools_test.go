@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/lithammer/dedent"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/davidovich/summon/internal/testutil"
+	"github.com/davidovich/summon/pkg/config"
+	"github.com/davidovich/summon/pkg/summon"
+)
+
+func TestToolsCmd(t *testing.T) {
+	defer testutil.ReplaceFs()()
+	t.Setenv("XDG_CACHE_HOME", "/cache")
+
+	configFile := dedent.Dedent(`
+		exec:
+		  handles:
+		    gohack:
+		      go: {module: github.com/rogpeppe/gohack, version: v1.0.2}
+		`)
+	testFs := fstest.MapFS{}
+	testFs["assets/"+config.ConfigFileName] = &fstest.MapFile{Data: []byte(configFile)}
+
+	s, err := summon.New(testFs)
+	require.NoError(t, err)
+
+	toolDir := filepath.Join("/cache", "summon", "bin", "github.com", "rogpeppe")
+	orphan := filepath.Join(toolDir, "gohack@v1.0.1")
+	require.NoError(t, testutil.GetFs().MkdirAll(orphan, 0o755))
+	require.NoError(t, afero.WriteFile(testutil.GetFs(), filepath.Join(toolDir, "gohack@v1.0.2", "gohack"), nil, 0o755))
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			name:     "list",
+			args:     []string{"tools", "list"},
+			expected: "gohack  github.com/rogpeppe/gohack@v1.0.2  installed  " + filepath.Join(toolDir, "gohack@v1.0.2", "gohack") + "\n",
+		},
+		{
+			name:     "prune",
+			args:     []string{"tools", "prune"},
+			expected: "removed " + orphan + "\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, err := CreateRootCmd(s, append([]string{"summon"}, tt.args...), summon.MainOptions{})
+			require.NoError(t, err)
+
+			b := &bytes.Buffer{}
+			root.SetOut(b)
+			err = root.Execute()
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, b.String())
+		})
+	}
+}
@@ -21,6 +21,7 @@ package summon
 
 import (
 	"embed"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -43,7 +44,7 @@ func Main(args []string, fs embed.FS, opts ...option) int {
 	}
 
 	summon.Name = args[0]
-	s, err := summon.New(fs)
+	s, err := summon.New(fs, summon.WithExecMiddleware(options.ExecMiddlewares...))
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "unable to create initial filesystem: %v\n", err)
 		return 1
@@ -52,7 +53,8 @@ func Main(args []string, fs embed.FS, opts ...option) int {
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
 	go func() {
-		for range c {
+		for sig := range c {
+			summon.ForwardSignal(sig)
 			os.Exit(0)
 		}
 	}()
@@ -65,7 +67,8 @@ func Main(args []string, fs embed.FS, opts ...option) int {
 
 	err = rootCmd.Execute()
 	if err != nil {
-		if exitError, ok := err.(*exec.ExitError); ok {
+		var exitError *exec.ExitError
+		if errors.As(err, &exitError) {
 			return exitError.ExitCode()
 		}
 		return 1
@@ -84,5 +87,16 @@ func WithoutRunCmd() option {
 	}
 }
 
+// WithExecMiddleware wraps the execution of every command summon runs, like
+// to log, measure, sandbox or refuse them. See summon.WithExecMiddleware.
+func WithExecMiddleware(m func(next Executor) Executor) option {
+	return func(o *MainOptions) {
+		o.ExecMiddlewares = append(o.ExecMiddlewares, m)
+	}
+}
+
 // MainOptions hold compile-time configurations.
 type MainOptions = summon.MainOptions
+
+// Executor executes a command, see WithExecMiddleware.
+type Executor = summon.Executor
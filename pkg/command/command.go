@@ -14,6 +14,15 @@ type ExecCommandFn func(string, ...string) *Cmd
 type Cmd struct {
 	*exec.Cmd
 	Run func() error
+	// Exec replaces the current process with the command. It is nil when the
+	// platform (or the factory) does not support process replacement, in which
+	// case callers should fall back to Run.
+	Exec func() error
+	// Start starts the command and Wait waits for it to exit, so that it can
+	// be killed while it runs. They are nil when the factory only provides
+	// Run, in which case callers should fall back to Run.
+	Start func() error
+	Wait  func() error
 }
 
 // New is the default factory that creates a Cmd with an os exec.Cmd Run function.
@@ -24,5 +33,8 @@ func New(c string, args ...string) *Cmd {
 	cmd.Run = func() error {
 		return cmd.Cmd.Run()
 	}
+	cmd.Exec = execFn(cmd)
+	cmd.Start = cmd.Cmd.Start
+	cmd.Wait = cmd.Cmd.Wait
 	return cmd
 }
@@ -0,0 +1,32 @@
+//go:build !windows
+
+package command
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// execFn returns a function that replaces the current process image with cmd.
+func execFn(cmd *Cmd) func() error {
+	return func() error {
+		if cmd.Err != nil {
+			return cmd.Err
+		}
+		path, err := exec.LookPath(cmd.Path)
+		if err != nil {
+			return err
+		}
+		env := cmd.Env
+		if env == nil {
+			env = os.Environ()
+		}
+		if cmd.Dir != "" {
+			if err := os.Chdir(cmd.Dir); err != nil {
+				return err
+			}
+		}
+		return syscall.Exec(path, cmd.Args, env)
+	}
+}
@@ -0,0 +1,6 @@
+package command
+
+// execFn returns nil as process replacement is not available on windows.
+func execFn(cmd *Cmd) func() error {
+	return nil
+}
@@ -13,6 +13,9 @@ const (
 
 	// ConfigFileName is the name of the summon config file.
 	ConfigFileName = "summon.config.yaml"
+
+	// DefaultPartials is the glob of the template partials assets.
+	DefaultPartials = "_templates/*.tpl"
 )
 
 // Alias gives a shortcut to a name in data.
@@ -26,12 +29,83 @@ type Config struct {
 	TemplateContext  string      `yaml:"templates"`
 	Exec             ExecContext `yaml:"exec"`
 	HideAssetsInHelp bool        `yaml:"hideAssetsInHelp"`
+	Modes            Modes       `yaml:"modes"`
+	Secrets          SecretsSpec `yaml:"secrets"`
+	// Partials is the glob of the assets parsed as templates available to
+	// handles and assets, DefaultPartials by default. Their directory is
+	// not listed nor summoned.
+	Partials string `yaml:"partials"`
+	// Render controls the template rendering of summoned assets.
+	Render RenderSpec `yaml:"render"`
+	// Strict makes templates fail on missing keys instead of rendering an
+	// empty string, like the --strict flag.
+	Strict bool `yaml:"strict"`
+}
+
+// RenderSpec controls the template rendering of summoned assets.
+type RenderSpec struct {
+	// Suffix, when set (like .tmpl), restricts rendering to the assets
+	// ending with it. The suffix is removed from the summoned file name and
+	// other assets are copied as-is.
+	Suffix string `yaml:"suffix,omitempty"`
+	// Rules map asset globs to how matching assets are rendered. Globs use /
+	// as separator and can contain ** to match any number of directories.
+	// Globs without a / match the base name of assets. The longest matching
+	// glob wins.
+	Rules map[string]RenderRule `yaml:"rules,omitempty"`
+}
+
+// RenderRule describes how an asset is rendered.
+type RenderRule struct {
+	// Raw copies the asset as-is, without rendering its name or content.
+	Raw bool `yaml:"raw,omitempty"`
+	// Delims are the left and right template delimiters (like ['[[', ']]'])
+	// used instead of {{ and }}.
+	Delims []string `yaml:"delims,omitempty"`
+}
+
+// SecretsSpec describes the secrets that are replaced by *** in diagnostic
+// output, like --debug, --dry-run and explain. Values still reach the
+// executed commands.
+type SecretsSpec struct {
+	// Env are globs of environment variable names (like *_TOKEN) whose values
+	// are secret. Variables loaded from env files are included.
+	Env []string `yaml:"env,omitempty"`
+	// Data are globs of dotted template data paths (like creds.token for the
+	// --json data) whose values are secret. Globs without a dot match keys at
+	// any depth, and all the values under a matched key are secret.
+	Data []string `yaml:"data,omitempty"`
+	// Patterns are regular expressions matching secret values
+	Patterns []string `yaml:"patterns,omitempty"`
 }
 
+// Modes maps asset globs to the octal file mode (like "0755") of summoned
+// files. A ** segment matches any number of directories, and globs without a
+// / match the base name of assets.
+type Modes map[string]string
+
 // ExecContext houses execution handles and global flags
 type ExecContext struct {
 	ExecEnv     map[string]ExecDesc `yaml:"handles"`
 	GlobalFlags map[string]FlagDesc `yaml:"flags"`
+	// EnvFile are dotenv files loaded for all handles, see CmdDesc.EnvFile
+	EnvFile []string `yaml:"envFile"`
+	// Hooks run around all handles, see HooksSpec
+	Hooks HooksSpec `yaml:"hooks"`
+}
+
+// HooksSpec describes commands run around a handle command. Each hook is a
+// string referencing a handle (followed by its args), or a list holding an
+// inline command. Hooks can contain templates, and after and onError hooks
+// can use the .exitCode and .duration of the handle command.
+type HooksSpec struct {
+	// Before hooks run before the command. The command does not run if one
+	// of them fails.
+	Before ArgSliceSpec `yaml:"before,omitempty"`
+	// After hooks run after the command succeeds.
+	After ArgSliceSpec `yaml:"after,omitempty"`
+	// OnError hooks run after the command, or a before hook, fails.
+	OnError ArgSliceSpec `yaml:"onError,omitempty"`
 }
 
 // ExecDesc allows unmarshalling complex subtype. Can be a slice of
@@ -56,6 +130,19 @@ type CmdDesc struct {
 	Prompts string `yaml:"prompts"`
 	// Args contain the args that get appended to the ExecEnvironment
 	Args ArgSliceSpec `yaml:"args"`
+	// Script is a templated script body that is written to a temporary file
+	// and run with the Interpreter. It cannot be used with Cmd.
+	Script string `yaml:"script,omitempty"`
+	// Interpreter is the command that runs the Script (defaults to sh). The
+	// script file path is appended to it, followed by the user args.
+	Interpreter ArgSliceSpec `yaml:"interpreter,omitempty"`
+	// Go runs a go tool pinned at a version instead of Cmd, see GoToolSpec.
+	Go *GoToolSpec `yaml:"go,omitempty"`
+	// Container runs the Cmd inside a container, see ContainerSpec.
+	Container *ContainerSpec `yaml:"container,omitempty"`
+	// Stdin is rendered and fed to the standard input of the command instead
+	// of the summon standard input. It can contain templates.
+	Stdin string `yaml:"stdin,omitempty"`
 	// SubCmd describes a sub-command of current command
 	SubCmd map[string]ExecDesc `yaml:"subCmd,omitempty"`
 	// Flags of this command
@@ -69,6 +156,76 @@ type CmdDesc struct {
 	Hidden bool `yaml:"hidden,omitempty"`
 	// Join joins arguments to form one argument of one line of text
 	Join *bool `yaml:"join,omitempty"`
+	// Exec replaces the summon process with the command instead of starting
+	// a child process.
+	Exec *bool `yaml:"exec,omitempty"`
+	// Watch re-runs the command when files change, see WatchSpec.
+	Watch *WatchSpec `yaml:"watch,omitempty"`
+	// Confirm is a question the user must answer yes to before the command
+	// is executed. It can contain templates.
+	Confirm string `yaml:"confirm,omitempty"`
+	// ConfirmTyping is a value the user must type before the command is
+	// executed, like the name of the resource to delete. It can contain
+	// templates.
+	ConfirmTyping string `yaml:"confirmTyping,omitempty"`
+	// EnvFile are dotenv files loaded in the environment of the command and
+	// in the .envfile template data. Relative paths are resolved in the
+	// current directory, then in the project root. Paths can contain
+	// templates and are optional when prefixed with -.
+	EnvFile []string `yaml:"envFile,omitempty"`
+	// Hooks run around the command. They run inside the global hooks.
+	HooksSpec `yaml:",inline"`
+}
+
+// WatchSpec describes the files that trigger a new run of a command when they
+// change. Globs are relative to the current directory, use / as separator and
+// can contain ** to match any number of directories. Globs without a / match
+// the base name of files.
+type WatchSpec struct {
+	// Paths are the globs of watched files
+	Paths []string `yaml:"paths"`
+	// Ignore are the globs of files and directories that are not watched
+	Ignore []string `yaml:"ignore,omitempty"`
+	// Debounce is the quiet duration (like 300ms) after a change before the
+	// command is re-run.
+	Debounce string `yaml:"debounce,omitempty"`
+}
+
+// GoToolSpec describes a go tool that summon installs once per version in its
+// cache directory and runs from there.
+type GoToolSpec struct {
+	// Module is the package path of the tool, as passed to go install
+	Module string `yaml:"module"`
+	// Version is the module version (or query) of the tool
+	Version string `yaml:"version"`
+}
+
+// ContainerSpec describes a container in which a command is run. Summon
+// expands it to the corresponding container engine invocation. All string
+// values can contain templates.
+type ContainerSpec struct {
+	// Image is the container image to run
+	Image string `yaml:"image"`
+	// Engine is the container engine, docker (default) or podman
+	Engine string `yaml:"engine,omitempty"`
+	// Mounts are volume mounts in the host-path:container-path[:options] form
+	Mounts []string `yaml:"mounts,omitempty"`
+	// Workdir is the working directory in the container, /workdir by default.
+	// The current directory is mounted there unless MountPwd is false.
+	Workdir string `yaml:"workdir,omitempty"`
+	// MountPwd controls the mount of the current directory on Workdir
+	MountPwd *bool `yaml:"mountPwd,omitempty"`
+	// User is the user running in the container. "current" maps the current
+	// user uid and gid.
+	User string `yaml:"user,omitempty"`
+	// TTY allocates a pseudo terminal: auto (default, when summon runs in a
+	// terminal), always or never.
+	TTY string `yaml:"tty,omitempty"`
+	// Env are environment variables in the NAME=value form, or NAME to pass
+	// the host value.
+	Env []string `yaml:"env,omitempty"`
+	// Network is the network to connect the container to
+	Network string `yaml:"network,omitempty"`
 }
 
 // FlagDesc describes a simple string flag or complex FlagSpec
@@ -93,6 +250,8 @@ type FlagSpec struct {
 	// function). The default is to add the rendered flag on the command line (implicit).
 	// Note that using the {{ flagValue "my-flag" }} in a template makes the Flag Explicit.
 	Explicit bool `yaml:"explicit"`
+	// Secret masks the value of the flag in diagnostic output.
+	Secret bool `yaml:"secret"`
 }
 
 // UnmarshalYAML the FlagSpec. It can be a String or a Flag
@@ -0,0 +1,59 @@
+package summon
+
+import (
+	"fmt"
+	"os"
+)
+
+// stdinIsTerminal returns true if the user can answer a confirmation.
+var stdinIsTerminal = isTerminalStdin
+
+func isTerminalStdin() bool { return isTerminal(os.Stdin) }
+
+// confirm asks the user to confirm the execution of cmd if the handle
+// declares confirm or confirmTyping. It fails if the user declines, or if
+// summon cannot ask because stdin is not a terminal and --yes was not given.
+func (d *Driver) confirm(ref string, cmd fmt.Stringer) error {
+	cmdSpec, _ := d.getCmdSpec()
+	if cmdSpec == nil || cmdSpec.confirm == "" && cmdSpec.confirmTyping == "" || d.opts.yes {
+		return nil
+	}
+	if !stdinIsTerminal() {
+		return fmt.Errorf("[%s] requires confirmation but stdin is not a terminal, use --yes to execute it anyway", ref)
+	}
+
+	// the confirmation must not change the args appended to the command
+	consumed := snapshot(d.inv.argsConsumed)
+	defer func() { d.inv.argsConsumed = consumed }()
+
+	fmt.Fprintf(d.stderr(), "About to execute [%s] -> `%s`\n", ref, d.mask(cmd.String()))
+	if cmdSpec.confirm != "" {
+		question, err := d.renderTemplate(cmdSpec.confirm)
+		if err != nil {
+			return fmt.Errorf("could not render confirm for exec handle '%s': %w", ref, err)
+		}
+		d.prompter.NewPrompt(question)
+		answer, err := d.prompter.Choose([]string{"no", "yes"})
+		if err != nil {
+			return err
+		}
+		if answer != "yes" {
+			return fmt.Errorf("[%s] was not confirmed", ref)
+		}
+	}
+	if cmdSpec.confirmTyping != "" {
+		expected, err := d.renderTemplate(cmdSpec.confirmTyping)
+		if err != nil {
+			return fmt.Errorf("could not render confirmTyping for exec handle '%s': %w", ref, err)
+		}
+		d.prompter.NewPrompt(fmt.Sprintf("Type %q to confirm", expected))
+		typed, err := d.prompter.Input("")
+		if err != nil {
+			return err
+		}
+		if typed != expected {
+			return fmt.Errorf("[%s] was not confirmed, typed %q instead of %q", ref, typed, expected)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package summon
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"testing/fstest"
+
+	"github.com/lithammer/dedent"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/davidovich/summon/internal/testutil"
+	"github.com/davidovich/summon/pkg/config"
+)
+
+func TestConfirm(t *testing.T) {
+	confirmConfig := dedent.Dedent(`
+		exec:
+		  handles:
+		    delete:
+		      cmd: [kubectl, delete, namespace]
+		      confirm: 'This will delete the {{ arg 0 }} namespace. Continue?'
+		    delete-typing:
+		      cmd: [kubectl, delete, namespace]
+		      confirmTyping: '{{ arg 0 }}'
+		`)
+
+	testCases := []struct {
+		desc       string
+		handle     string
+		yes        bool
+		noTerminal bool
+		devNull    bool
+		prompter   *testPrompter
+		wantPrompt string
+		wantError  string
+	}{
+		{
+			desc:       "confirmed",
+			handle:     "delete",
+			prompter:   &testPrompter{choice: 1},
+			wantPrompt: "This will delete the prod namespace. Continue?",
+		},
+		{
+			desc:      "declined",
+			handle:    "delete",
+			prompter:  &testPrompter{choice: 0},
+			wantError: "[delete] was not confirmed",
+		},
+		{
+			desc:       "typed",
+			handle:     "delete-typing",
+			prompter:   &testPrompter{userInput: "prod"},
+			wantPrompt: `Type "prod" to confirm`,
+		},
+		{
+			desc:      "mistyped",
+			handle:    "delete-typing",
+			prompter:  &testPrompter{userInput: "pro"},
+			wantError: `typed "pro" instead of "prod"`,
+		},
+		{
+			desc:       "yes-bypasses",
+			handle:     "delete",
+			yes:        true,
+			noTerminal: true,
+			prompter:   &testPrompter{},
+		},
+		{
+			desc:       "no-terminal",
+			handle:     "delete",
+			noTerminal: true,
+			prompter:   &testPrompter{choice: 1},
+			wantError:  "stdin is not a terminal, use --yes",
+		},
+		{
+			desc:      "dev-null-stdin",
+			handle:    "delete",
+			devNull:   true,
+			prompter:  &testPrompter{choice: 1},
+			wantError: "stdin is not a terminal, use --yes",
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			defer func(isTerminal func() bool) { stdinIsTerminal = isTerminal }(stdinIsTerminal)
+			stdinIsTerminal = func() bool { return !tC.noTerminal }
+			if tC.devNull {
+				// like cron or CI, with the real terminal detection
+				stdinIsTerminal = isTerminalStdin
+				devNull, err := os.Open(os.DevNull)
+				require.NoError(t, err)
+				defer devNull.Close()
+				defer func(stdin *os.File) { os.Stdin = stdin }(os.Stdin)
+				os.Stdin = devNull
+			}
+
+			testFs := fstest.MapFS{}
+			testFs[config.ConfigFileName] = &fstest.MapFile{Data: []byte(confirmConfig)}
+
+			execCmd := testutil.FakeExecCommand("TestSummonRunHelper")
+			s, err := New(testFs, ExecCmd(execCmd.Fn), WithPrompter(tC.prompter), Yes(tC.yes))
+			require.NoError(t, err)
+			_, err = s.ConstructCommandTree(&cobra.Command{Use: "root"}, false)
+			require.NoError(t, err)
+
+			stderr := &bytes.Buffer{}
+			err = s.Run(Ref(tC.handle), Args("prod"), Err(stderr))
+			if tC.wantError != "" {
+				assert.ErrorContains(t, err, tC.wantError)
+				assert.Empty(t, execCmd.GetCalls())
+				return
+			}
+			require.NoError(t, err)
+			if tC.wantPrompt != "" {
+				assert.Equal(t, tC.wantPrompt, tC.prompter.b.String())
+				assert.Contains(t, stderr.String(), "About to execute [delete")
+			}
+			// rendering the confirmation does not consume the args
+			calls := execCmd.GetCalls()
+			require.Len(t, calls, 1)
+			assert.Equal(t, []string{"kubectl", "delete", "namespace", "prod"}, calls[0].Args)
+		})
+	}
+}
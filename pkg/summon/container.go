@@ -0,0 +1,132 @@
+package summon
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"golang.org/x/term"
+
+	"github.com/davidovich/summon/pkg/config"
+)
+
+const defaultContainerWorkdir = "/workdir"
+
+// containerArgs renders the container engine invocation that precedes the
+// command of a container handle.
+func (d *Driver) containerArgs(c *config.ContainerSpec) ([]string, error) {
+	render := func(s string) (string, error) {
+		if s == "" {
+			return "", nil
+		}
+		return d.renderTemplate(s)
+	}
+
+	engine, err := render(c.Engine)
+	if err != nil {
+		return nil, err
+	}
+	switch engine {
+	case "":
+		engine = "docker"
+	case "docker", "podman":
+	default:
+		return nil, fmt.Errorf("unsupported container engine %q, use docker or podman", engine)
+	}
+
+	image, err := render(c.Image)
+	if err != nil {
+		return nil, err
+	}
+	if image == "" {
+		return nil, fmt.Errorf("container image is required")
+	}
+
+	args := []string{engine, "run", "--rm", "-i"}
+
+	tty, err := render(c.TTY)
+	if err != nil {
+		return nil, err
+	}
+	switch tty {
+	case "", "auto":
+		if d.opts.out == os.Stdout && isTerminal(os.Stdin) && isTerminal(os.Stdout) {
+			args = append(args, "-t")
+		}
+	case "always", "true":
+		args = append(args, "-t")
+	case "never", "false":
+	default:
+		return nil, fmt.Errorf("invalid tty value %q, use auto, always or never", tty)
+	}
+
+	workdir, err := render(c.Workdir)
+	if err != nil {
+		return nil, err
+	}
+	if workdir == "" {
+		workdir = defaultContainerWorkdir
+	}
+	if c.MountPwd == nil || *c.MountPwd {
+		pwd, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, "-v", pwd+":"+workdir)
+	}
+	args = append(args, "-w", workdir)
+
+	for _, m := range c.Mounts {
+		mount, err := render(m)
+		if err != nil {
+			return nil, err
+		}
+		if mount != "" {
+			args = append(args, "-v", mount)
+		}
+	}
+
+	user, err := render(c.User)
+	if err != nil {
+		return nil, err
+	}
+	if user == "current" {
+		user = ""
+		if uid, gid := os.Getuid(), os.Getgid(); uid >= 0 {
+			user = strconv.Itoa(uid) + ":" + strconv.Itoa(gid)
+			if engine == "podman" {
+				// rootless podman needs the user namespace to keep the uid
+				args = append(args, "--userns=keep-id")
+			}
+		}
+	}
+	if user != "" {
+		args = append(args, "-u", user)
+	}
+
+	for _, e := range c.Env {
+		env, err := render(e)
+		if err != nil {
+			return nil, err
+		}
+		if env != "" {
+			args = append(args, "-e", env)
+		}
+	}
+
+	network, err := render(c.Network)
+	if err != nil {
+		return nil, err
+	}
+	if network != "" {
+		args = append(args, "--network", network)
+	}
+
+	return append(args, image), nil
+}
+
+// isTerminal returns true if f is a terminal. Character devices like
+// /dev/null are not.
+func isTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
+}
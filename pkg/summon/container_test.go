@@ -0,0 +1,133 @@
+package summon
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+	"testing"
+	"testing/fstest"
+
+	"github.com/lithammer/dedent"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/davidovich/summon/pkg/command"
+	"github.com/davidovich/summon/pkg/config"
+)
+
+func TestContainerHandles(t *testing.T) {
+	configFile := dedent.Dedent(`
+		exec:
+		  handles:
+		    ls:
+		      container:
+		        image: alpine
+		      cmd: [ls]
+		    full:
+		      container:
+		        image: 'alpine:{{ env "ALPINE_VERSION" | default "3.19" }}'
+		        engine: podman
+		        workdir: /src
+		        mounts: ['/cache:/root/.cache']
+		        user: current
+		        tty: always
+		        env: [HOME=/root, TOKEN]
+		        network: host
+		      cmd: [sh, -c]
+		      subCmd:
+		        sub: [echo, sub]
+		    no-pwd:
+		      container:
+		        image: alpine
+		        mountPwd: false
+		        tty: never
+		        user: '1000'
+		    bad-engine:
+		      container:
+		        image: alpine
+		        engine: rkt
+		`)
+	testFs := fstest.MapFS{}
+	testFs[config.ConfigFileName] = &fstest.MapFile{Data: []byte(configFile)}
+
+	pwd, err := os.Getwd()
+	require.NoError(t, err)
+	currentUser := strconv.Itoa(os.Getuid()) + ":" + strconv.Itoa(os.Getgid())
+
+	tests := []struct {
+		name    string
+		args    []string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "defaults",
+			args: []string{"ls", "-al"},
+			want: []string{"docker", "run", "--rm", "-i", "-v", pwd + ":/workdir", "-w", "/workdir", "alpine", "ls", "-al"},
+		},
+		{
+			name: "all-options",
+			args: []string{"full", "echo hello"},
+			want: []string{"podman", "run", "--rm", "-i", "-t", "-v", pwd + ":/src", "-w", "/src",
+				"-v", "/cache:/root/.cache", "--userns=keep-id", "-u", currentUser,
+				"-e", "HOME=/root", "-e", "TOKEN", "--network", "host",
+				"alpine:3.19", "sh", "-c", "echo hello"},
+		},
+		{
+			name: "sub-command-inherits-container",
+			args: []string{"full", "sub"},
+			want: []string{"podman", "run", "--rm", "-i", "-t", "-v", pwd + ":/src", "-w", "/src",
+				"-v", "/cache:/root/.cache", "--userns=keep-id", "-u", currentUser,
+				"-e", "HOME=/root", "-e", "TOKEN", "--network", "host",
+				"alpine:3.19", "sh", "-c", "echo", "sub"},
+		},
+		{
+			name: "no-pwd-mount",
+			args: []string{"no-pwd", "ls"},
+			want: []string{"docker", "run", "--rm", "-i", "-w", "/workdir", "-u", "1000", "alpine", "ls"},
+		},
+		{
+			name:    "unsupported-engine",
+			args:    []string{"bad-engine"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			s, err := New(testFs, ExecCmd(func(c string, args ...string) *command.Cmd {
+				return &command.Cmd{
+					Cmd: &exec.Cmd{},
+					Run: func() error {
+						got = append([]string{c}, args...)
+						return nil
+					},
+				}
+			}))
+			require.NoError(t, err)
+			root, err := s.ConstructCommandTree(&cobra.Command{Use: "root"}, false)
+			require.NoError(t, err)
+
+			cobraCmd, args, err := root.Find(tt.args)
+			require.NoError(t, err)
+
+			err = s.Run(CobraCmd(cobraCmd), Args(args...))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestIsTerminal(t *testing.T) {
+	devNull, err := os.Open(os.DevNull)
+	require.NoError(t, err)
+	defer devNull.Close()
+
+	// /dev/null is a character device, but not a terminal
+	assert.False(t, isTerminal(devNull))
+}
@@ -1,9 +1,11 @@
 package summon
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"io/fs"
+	"maps"
 	"os"
 	"path"
 	"text/template"
@@ -21,7 +23,9 @@ type Summoner = Driver
 // Name holds the name of the driver executable. By default it is "summon"
 var Name = "summon"
 
-// Driver manages functionality of summon.
+// Driver manages functionality of summon. It holds the configuration, which is
+// not modified by runs of handles: each Run works on a copy of the Driver with
+// its own invocation, so a Driver can run handles concurrently and repeatedly.
 type Driver struct {
 	opts          options
 	config        config.Config
@@ -34,8 +38,54 @@ type Driver struct {
 	configRead    bool
 	flagsToRender []*flagValue
 	cmdToSpec     map[*cobra.Command]*commandSpec
-	prompts       map[string]string
 	prompter      Prompter
+	// watchStop stops watching when closed, watching never stops when nil
+	watchStop chan struct{}
+	// secrets are the rules of what is masked in diagnostic output
+	secrets *secrets
+	// inv is the state of the current invocation
+	inv *invocation
+}
+
+// invocation is the state of one run of a handle.
+type invocation struct {
+	// ctx kills the commands of the invocation when done
+	ctx context.Context
+	// result is the outcome of the command of the invocation
+	result Result
+	// argsConsumed are the indexes of the args used by templates
+	argsConsumed map[int]struct{}
+	// flags are the copies of flagsToRender rendered by this invocation
+	flags []*flagValue
+	// prompts are the prompt results by slot, shared with nested invocations
+	prompts map[string]string
+	// secrets are masked in diagnostic output, shared with nested invocations
+	secrets *secrets
+	// includes are the assets being rendered, the last one including the
+	// next asset
+	includes []string
+	// ephemeralDir holds the files written by the summon template function
+	ephemeralDir string
+	// nested is true for invocations of run template function calls and hooks
+	nested bool
+	// noExec prevents replacing the process, like in RunContext which returns
+	// a Result
+	noExec bool
+	// nestedPlans are the dry-run plans of run template function calls
+	nestedPlans []*Plan
+	// lastPlan is the dry-run plan of the invocation
+	lastPlan *Plan
+	// explanation traces the rendering of the command line when explaining
+	explanation *explanation
+}
+
+func newInvocation(s *secrets) *invocation {
+	return &invocation{
+		ctx:          context.Background(),
+		argsConsumed: map[int]struct{}{},
+		prompts:      map[string]string{},
+		secrets:      s.fresh(),
+	}
 }
 
 // New creates the Driver.
@@ -44,7 +94,6 @@ func New(filesystem fs.FS, opts ...Option) (*Driver, error) {
 		fs:          filesystem,
 		execCommand: command.New,
 		cmdToSpec:   map[*cobra.Command]*commandSpec{},
-		prompts:     map[string]string{},
 		prompter:    &Prompt{},
 	}
 
@@ -67,6 +116,11 @@ func New(filesystem fs.FS, opts ...Option) (*Driver, error) {
 		return nil, err
 	}
 
+	err = d.loadPartials()
+	if err != nil {
+		return nil, err
+	}
+
 	return d, nil
 }
 
@@ -100,16 +154,25 @@ func (d *Driver) Configure(opts ...Option) error {
 			if err != nil {
 				return err
 			}
+			lenientRequired(d.templateCtx)
 			// prime execContext cache
 			_, _, err = d.execContext()
 			if err != nil {
 				return err
 			}
+			d.secrets, err = newSecrets(d.config.Secrets)
+			if err != nil {
+				return err
+			}
 
 			d.configRead = true
 		}
 	}
 
+	if d.inv == nil {
+		d.inv = newInvocation(d.secrets)
+	}
+
 	for _, opt := range opts {
 		err := opt(&d.opts)
 		if err != nil {
@@ -144,6 +207,22 @@ func (d *Driver) Configure(opts ...Option) error {
 	return nil
 }
 
+// invoke returns a copy of d with a new invocation. The copy shares the
+// configuration of d and renders its own copies of the flags set on the
+// command line.
+func (d *Driver) invoke() *Driver {
+	c := *d
+	c.opts.data = maps.Clone(d.opts.data)
+	c.inv = newInvocation(d.secrets)
+	for _, f := range d.flagsToRender {
+		fv := *f
+		fv.d = &c
+		fv.rendered = ""
+		c.inv.flags = append(c.inv.flags, &fv)
+	}
+	return &c
+}
+
 // manage json manually
 type jsonValue struct {
 	d             Configurer
@@ -183,6 +262,32 @@ func (jv *jsonValue) String() string {
 
 func (jv *jsonValue) Type() string { return "string" }
 
+// dryRunValue manages the dry-run flag which is a boolean with an optional
+// output format.
+type dryRunValue struct {
+	opts *options
+}
+
+func (dr *dryRunValue) Set(s string) error {
+	switch s {
+	case "false":
+		dr.opts.dryrun = false
+		return nil
+	case "true":
+		s = dryRunText
+	}
+	return DryRunFormat(s)(dr.opts)
+}
+
+func (dr *dryRunValue) String() string {
+	if !dr.opts.dryrun {
+		return "false"
+	}
+	return dr.opts.dryRunFormat
+}
+
+func (dr *dryRunValue) Type() string { return "format" }
+
 func (d *Driver) RegisterFlags(runRoot *cobra.Command) {
 	json := &jsonValue{d: d, cmd: runRoot.Root()}
 	jsonFile := &jsonValue{d: d, cmd: runRoot.Root(), isFile: true, otherValueSet: &json.valueSet}
@@ -191,6 +296,13 @@ func (d *Driver) RegisterFlags(runRoot *cobra.Command) {
 	runRoot.Root().PersistentFlags().Var(json, "json", "json to use to render template")
 	runRoot.Root().PersistentFlags().Var(jsonFile, "json-file", "json file to use to render template, with '-' for stdin")
 
+	runRoot.Root().PersistentFlags().BoolVar(&d.opts.strict, "strict", false, "fail on missing keys in templates")
 	runRoot.Root().Flags().BoolVarP(&d.opts.debug, "debug", "d", false, "print debug info on stderr")
-	runRoot.Flags().BoolVarP(&d.opts.dryrun, "dry-run", "n", false, "only show what would be executed")
+	dryRun := runRoot.Flags().VarPF(&dryRunValue{opts: &d.opts}, "dry-run", "n", "only show what would be executed, with an optional format: text, json or sh")
+	dryRun.NoOptDefVal = dryRunText
+	runRoot.Flags().BoolVar(&d.opts.exec, "exec", false, "replace the summon process with the executed command")
+	runRoot.Flags().StringArrayVar(&d.opts.watch, "watch", nil, "re-run the command when files matching this glob change (repeatable)")
+	runRoot.Flags().BoolVar(&d.opts.quietStderr, "quiet-stderr", false, "silence the stderr of handles called by run template functions unless they fail")
+	runRoot.Flags().BoolVarP(&d.opts.yes, "yes", "y", false, "do not ask for confirmation before executing")
+	runRoot.Flags().StringArrayVar(&d.opts.watchIgnore, "watch-ignore", nil, "do not watch files matching this glob (repeatable)")
 }
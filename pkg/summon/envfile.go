@@ -0,0 +1,171 @@
+package summon
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+
+	"github.com/davidovich/summon/pkg/command"
+)
+
+// envVar is a variable loaded from an env file.
+type envVar struct {
+	name  string
+	value string
+}
+
+// loadEnvFiles renders the env file paths, global ones first, and loads
+// their variables in order. Later definitions override earlier ones. Paths
+// prefixed with - are optional.
+func (d *Driver) loadEnvFiles(envFiles []string) ([]envVar, error) {
+	var vars []envVar
+	defined := map[string]string{}
+	for _, f := range envFiles {
+		optional := strings.HasPrefix(f, "-")
+		rendered, err := d.renderTemplate(strings.TrimPrefix(f, "-"))
+		if err != nil {
+			return nil, fmt.Errorf("could not render envFile %q: %w", f, err)
+		}
+		path, found := resolveEnvFile(rendered)
+		if !found {
+			if optional {
+				continue
+			}
+			return nil, fmt.Errorf("envFile %s not found in the current directory or project root, prefix it with - if it is optional", rendered)
+		}
+		content, err := afero.ReadFile(appFs, path)
+		if err != nil {
+			return nil, err
+		}
+		fileVars, err := parseEnvFile(string(content), defined)
+		if err != nil {
+			return nil, fmt.Errorf("in envFile %s: %w", path, err)
+		}
+		vars = append(vars, fileVars...)
+	}
+	return vars, nil
+}
+
+// resolveEnvFile finds a relative env file in the current directory, or else
+// in the project root (the nearest parent containing .git).
+func resolveEnvFile(name string) (string, bool) {
+	if filepath.IsAbs(name) {
+		_, err := appFs.Stat(name)
+		return name, err == nil
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", false
+	}
+	if _, err := appFs.Stat(filepath.Join(cwd, name)); err == nil {
+		return filepath.Join(cwd, name), true
+	}
+	for dir := cwd; ; dir = filepath.Dir(dir) {
+		if _, err := appFs.Stat(filepath.Join(dir, ".git")); err == nil {
+			path := filepath.Join(dir, name)
+			_, err := appFs.Stat(path)
+			return path, err == nil
+		}
+		if filepath.Dir(dir) == dir {
+			return "", false
+		}
+	}
+}
+
+// parseEnvFile parses dotenv content. Values can be quoted. Unquoted and
+// double quoted values interpolate $VAR, ${VAR} and ${VAR:-default} with the
+// variables defined before them, then with the environment. defined is
+// updated with the parsed variables.
+func parseEnvFile(content string, defined map[string]string) ([]envVar, error) {
+	lookup := func(name string) string {
+		name, fallback, hasFallback := strings.Cut(name, ":-")
+		value, ok := defined[name]
+		if !ok {
+			value, ok = os.LookupEnv(name)
+		}
+		if (!ok || value == "") && hasFallback {
+			return fallback
+		}
+		return value
+	}
+
+	var vars []envVar
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		name, value, ok := strings.Cut(line, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" || strings.ContainsAny(name, " \t") {
+			return nil, fmt.Errorf("line %d: expected NAME=value", lineNo)
+		}
+		value = strings.TrimSpace(value)
+
+		switch {
+		case len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'':
+			value = value[1 : len(value)-1]
+		case len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"':
+			value = strings.NewReplacer(`\n`, "\n", `\"`, `"`, `\\`, `\`).Replace(value[1 : len(value)-1])
+			value = os.Expand(value, lookup)
+		default:
+			if comment := strings.Index(value, " #"); comment >= 0 {
+				value = strings.TrimSpace(value[:comment])
+			}
+			value = os.Expand(value, lookup)
+		}
+		defined[name] = value
+		vars = append(vars, envVar{name: name, value: value})
+	}
+	return vars, scanner.Err()
+}
+
+// envFileData returns the env file variables as template data.
+func envFileData(vars []envVar) map[string]interface{} {
+	data := make(map[string]interface{}, len(vars))
+	for _, v := range vars {
+		data[v.name] = v.value
+	}
+	return data
+}
+
+// envList returns the variables in the NAME=value form.
+func envList(vars []envVar) []string {
+	env := make([]string, 0, len(vars))
+	for _, v := range vars {
+		env = append(env, v.name+"="+v.value)
+	}
+	return env
+}
+
+// maskedEnv returns the NAME=*** form of env, to show which variables are set
+// without leaking their values.
+func maskedEnv(env []string) string {
+	masked := make([]string, 0, len(env))
+	for _, e := range env {
+		name, _, _ := strings.Cut(e, "=")
+		masked = append(masked, name+"=***")
+	}
+	return strings.Join(masked, " ")
+}
+
+// newCmd creates the command of a rendered handle, with its environment.
+func (d *Driver) newCmd(rendered *renderedCmd) *command.Cmd {
+	cmd := d.execCommand(rendered.args[0], rendered.args[1:]...)
+	if len(rendered.env) != 0 {
+		cmd.Env = append(os.Environ(), rendered.env...)
+	}
+	return cmd
+}
+
+// envFiles returns the global env files followed by the env files of the
+// handle.
+func (d *Driver) envFiles(cmdSpec *commandSpec) []string {
+	return append(append([]string{}, d.config.Exec.EnvFile...), cmdSpec.envFile...)
+}
@@ -0,0 +1,129 @@
+package summon
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/lithammer/dedent"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/davidovich/summon/internal/testutil"
+	"github.com/davidovich/summon/pkg/config"
+)
+
+func TestParseEnvFile(t *testing.T) {
+	t.Setenv("SUMMON_TEST_HOME", "/home/me")
+
+	content := dedent.Dedent(`
+		# a comment
+		HOST=db.local
+		export PORT=5432 # trailing comment
+		URL=postgres://${HOST}:$PORT/app
+		LITERAL='${HOST} # kept'
+		QUOTED="line1\nline2 ${HOST}"
+		FROM_ENV=$SUMMON_TEST_HOME/data
+		DEFAULTED=${SUMMON_TEST_UNSET:-fallback}
+		EMPTY=
+		`)
+	vars, err := parseEnvFile(content, map[string]string{})
+	require.NoError(t, err)
+	assert.Equal(t, []envVar{
+		{"HOST", "db.local"},
+		{"PORT", "5432"},
+		{"URL", "postgres://db.local:5432/app"},
+		{"LITERAL", "${HOST} # kept"},
+		{"QUOTED", "line1\nline2 db.local"},
+		{"FROM_ENV", "/home/me/data"},
+		{"DEFAULTED", "fallback"},
+		{"EMPTY", ""},
+	}, vars)
+
+	_, err = parseEnvFile("NOT A VAR", map[string]string{})
+	assert.ErrorContains(t, err, "line 1: expected NAME=value")
+}
+
+func TestEnvFile(t *testing.T) {
+	defer testutil.ReplaceFs()()
+
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	root := filepath.Dir(cwd)
+	require.NoError(t, appFs.MkdirAll(filepath.Join(root, ".git"), 0o755))
+	require.NoError(t, afero.WriteFile(appFs, filepath.Join(root, ".env"), []byte("SHARED=root\nNAME=root"), 0o644))
+	require.NoError(t, afero.WriteFile(appFs, filepath.Join(cwd, ".env.prod"), []byte("NAME=prod\nGREETING=hello $NAME"), 0o644))
+
+	envConfig := dedent.Dedent(`
+		exec:
+		  envFile: [.env]
+		  handles:
+		    greet:
+		      cmd: [echo, '{{ .envfile.GREETING }}', '{{ .envfile.SHARED }}']
+		      envFile: ['.env.{{ .profile }}', -.env.local]
+		      subCmd:
+		        sub: [sub]
+		    missing:
+		      cmd: [echo]
+		      envFile: [.env.missing]
+		`)
+
+	testCases := []struct {
+		desc      string
+		ref       string
+		wantArgs  []string
+		wantEnv   []string
+		wantError string
+	}{
+		{
+			desc:     "global-then-handle",
+			ref:      "greet",
+			wantArgs: []string{"echo", "hello prod", "root"},
+			wantEnv:  []string{"SHARED=root", "NAME=root", "NAME=prod", "GREETING=hello prod"},
+		},
+		{
+			desc:     "inherited-by-sub-commands",
+			ref:      "sub",
+			wantArgs: []string{"echo", "hello prod", "root", "sub"},
+			wantEnv:  []string{"SHARED=root", "NAME=root", "NAME=prod", "GREETING=hello prod"},
+		},
+		{
+			desc:      "missing",
+			ref:       "missing",
+			wantError: "envFile .env.missing not found",
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			testFs := fstest.MapFS{}
+			testFs[config.ConfigFileName] = &fstest.MapFile{Data: []byte(envConfig)}
+
+			execCmd := testutil.FakeExecCommand("TestSummonRunHelper")
+			data := `{"profile": "prod"}`
+			s, err := New(testFs, ExecCmd(execCmd.Fn), JSON(&data))
+			require.NoError(t, err)
+			_, err = s.ConstructCommandTree(&cobra.Command{Use: "root"}, false)
+			require.NoError(t, err)
+
+			err = s.Run(Ref(tC.ref))
+			if tC.wantError != "" {
+				assert.ErrorContains(t, err, tC.wantError)
+				return
+			}
+			require.NoError(t, err)
+
+			calls := execCmd.GetCalls()
+			require.Len(t, calls, 1)
+			assert.Equal(t, tC.wantArgs, calls[0].Args)
+			assert.Subset(t, calls[0].Env, tC.wantEnv)
+			assert.Equal(t, tC.wantEnv, calls[0].Env[len(calls[0].Env)-len(tC.wantEnv):])
+		})
+	}
+}
+
+func TestMaskedEnv(t *testing.T) {
+	assert.Equal(t, "TOKEN=*** EMPTY=***", maskedEnv([]string{"TOKEN=secret", "EMPTY="}))
+}
@@ -0,0 +1,87 @@
+package summon
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"sync"
+
+	"github.com/davidovich/summon/pkg/command"
+)
+
+// Executor executes a command.
+type Executor func(cmd *command.Cmd) error
+
+// execute executes cmd with run, wrapped by the exec middlewares. The first
+// middleware is the outermost.
+func (d *Driver) execute(cmd *command.Cmd, run Executor) error {
+	for i := len(d.opts.execMiddlewares) - 1; i >= 0; i-- {
+		run = d.opts.execMiddlewares[i](run)
+	}
+	return run(cmd)
+}
+
+// runCmd runs cmd until the context of the invocation is done.
+func (d *Driver) runCmd(cmd *command.Cmd) error {
+	return d.runCmdContext(d.inv.ctx, cmd)
+}
+
+// runCmdContext runs cmd through the exec middlewares and kills it, with the
+// processes it started, when ctx is done. Commands without Start and Wait
+// functions are run with Run and cannot be killed.
+func (d *Driver) runCmdContext(ctx context.Context, cmd *command.Cmd) error {
+	return d.execute(cmd, func(cmd *command.Cmd) error {
+		if ctx.Done() == nil || cmd.Cmd == nil || cmd.Start == nil || cmd.Wait == nil {
+			return cmd.Run()
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		startGroup(cmd.Cmd)
+		if err := cmd.Start(); err != nil {
+			return err
+		}
+		running.add(cmd.Cmd)
+		defer running.remove(cmd.Cmd)
+		stop := context.AfterFunc(ctx, func() { killGroup(cmd.Cmd) })
+		defer stop()
+		err := cmd.Wait()
+		if ctx.Err() != nil {
+			return fmt.Errorf("%w: %w", ctx.Err(), err)
+		}
+		return err
+	})
+}
+
+// groups are the commands running in their own process group.
+type groups struct {
+	mu   sync.Mutex
+	cmds map[*exec.Cmd]struct{}
+}
+
+var running = &groups{cmds: map[*exec.Cmd]struct{}{}}
+
+func (g *groups) add(cmd *exec.Cmd) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.cmds[cmd] = struct{}{}
+}
+
+func (g *groups) remove(cmd *exec.Cmd) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.cmds, cmd)
+}
+
+// ForwardSignal sends sig to the commands running in their own process group,
+// like watched commands. They are not in the foreground process group of the
+// terminal, so they do not receive its signals, like the SIGINT of Ctrl-C.
+// Main calls it before exiting on a signal.
+func ForwardSignal(sig os.Signal) {
+	running.mu.Lock()
+	defer running.mu.Unlock()
+	for cmd := range running.cmds {
+		signalGroup(cmd, sig)
+	}
+}
@@ -0,0 +1,72 @@
+package summon
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"testing"
+	"testing/fstest"
+
+	"github.com/lithammer/dedent"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/davidovich/summon/pkg/command"
+	"github.com/davidovich/summon/pkg/config"
+)
+
+func TestExecMiddleware(t *testing.T) {
+	echo, err := exec.LookPath("echo")
+	if err != nil {
+		t.Skip("echo is needed to run the handles")
+	}
+	testFs := fstest.MapFS{}
+	testFs[config.ConfigFileName] = &fstest.MapFile{Data: []byte(dedent.Dedent(`
+		exec:
+		  handles:
+		    outer:
+		      cmd: [echo, '{{ run "inner" }}']
+		    inner:
+		      cmd: [echo, nested]
+		    forbidden:
+		      cmd: [echo, never]
+		`))}
+
+	var calls []string
+	named := func(name string) func(next Executor) Executor {
+		return func(next Executor) Executor {
+			return func(cmd *command.Cmd) error {
+				calls = append(calls, fmt.Sprintf("%s>%s", name, cmd.Args[1]))
+				return next(cmd)
+			}
+		}
+	}
+	// prefix all commands with echo, like a sandbox wrapper would
+	prefix := func(next Executor) Executor {
+		return func(cmd *command.Cmd) error {
+			cmd.Path = echo
+			cmd.Args = append([]string{"echo"}, cmd.Args...)
+			return next(cmd)
+		}
+	}
+	policy := func(next Executor) Executor {
+		return func(cmd *command.Cmd) error {
+			if cmd.Args[len(cmd.Args)-1] == "never" {
+				return fmt.Errorf("forbidden by policy")
+			}
+			return next(cmd)
+		}
+	}
+
+	s, err := New(testFs, WithExecMiddleware(named("a"), named("b")), WithExecMiddleware(policy, prefix))
+	require.NoError(t, err)
+
+	out := &bytes.Buffer{}
+	err = s.Run(Ref("outer"), Out(out))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a>nested", "b>nested", "a>echo nested", "b>echo nested"}, calls)
+	assert.Equal(t, "echo echo nested\n", out.String())
+
+	err = s.Run(Ref("forbidden"))
+	assert.ErrorContains(t, err, "forbidden by policy")
+}
@@ -0,0 +1,104 @@
+package summon
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// explanation traces how buildCmdArgs constructs the command line of a
+// handle.
+type explanation struct {
+	handle string
+	steps  []explainStep
+}
+
+// explainStep is one rendering step of a command line.
+type explainStep struct {
+	// section is the part of the handle being rendered (cmd, args, flags...)
+	section string
+	// raw is the template before rendering
+	raw string
+	// rendered is the result of rendering raw
+	rendered []string
+	// consumed are the user args consumed by this render
+	consumed []string
+	// note explains the effect of the step
+	note string
+}
+
+// add records a step if an explanation was requested.
+func (e *explanation) add(step explainStep) {
+	if e == nil {
+		return
+	}
+	e.steps = append(e.steps, step)
+}
+
+// consumedSince returns the user args consumed since the before snapshot of
+// consumed indexes.
+func consumedSince(args []string, before, after map[int]struct{}) []string {
+	var indexes []int
+	for i := range after {
+		if _, ok := before[i]; !ok && i < len(args) {
+			indexes = append(indexes, i)
+		}
+	}
+	sort.Ints(indexes)
+
+	var consumed []string
+	for _, i := range indexes {
+		consumed = append(consumed, strconv.Itoa(i)+":"+strconv.Quote(args[i]))
+	}
+	return consumed
+}
+
+// snapshot copies a set of consumed arg indexes.
+func snapshot(consumed map[int]struct{}) map[int]struct{} {
+	s := make(map[int]struct{}, len(consumed))
+	for k, v := range consumed {
+		s[k] = v
+	}
+	return s
+}
+
+func quoteAll(s []string) string {
+	q := make([]string, 0, len(s))
+	for _, e := range s {
+		q = append(q, strconv.Quote(e))
+	}
+	return "[" + strings.Join(q, " ") + "]"
+}
+
+// write prints the explanation followed by the resulting argv. Rendered
+// values are masked with mask.
+func (e *explanation) write(w io.Writer, argv []string, mask func(string) string) error {
+	fmt.Fprintf(w, "Explaining [%s]\n", e.handle)
+	section := ""
+	for _, s := range e.steps {
+		if s.section != section {
+			section = s.section
+			fmt.Fprintf(w, "%s:\n", section)
+		}
+		line := "  "
+		if s.raw != "" {
+			line += fmt.Sprintf("%s -> %s", strconv.Quote(s.raw), quoteAll(s.rendered))
+		} else if s.rendered != nil {
+			line += quoteAll(s.rendered)
+		}
+		if len(s.consumed) != 0 {
+			line += fmt.Sprintf(" (consumed user args %s)", strings.Join(s.consumed, ", "))
+		}
+		if s.note != "" {
+			if line != "  " {
+				line += " "
+			}
+			line += s.note
+		}
+		fmt.Fprintln(w, mask(line))
+	}
+	_, err := fmt.Fprintf(w, "result:\n  %s\n", mask(quoteAll(argv)))
+	return err
+}
@@ -0,0 +1,100 @@
+package summon
+
+import (
+	"bytes"
+	"testing"
+	"testing/fstest"
+
+	"github.com/lithammer/dedent"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/davidovich/summon/pkg/config"
+)
+
+var explainConfig = dedent.Dedent(`
+	exec:
+	  handles:
+	    greet:
+	      cmd: [echo]
+	      args: ['{{ arg 0 }}', '{{ flagValue "color" }}']
+	      flags:
+	        color: '--color={{ .flag }}'
+	        loud: '--loud={{ .flag }}'
+	    greet-all:
+	      cmd: [echo]
+	      args: ['{{ swallowargs }}']
+	`)
+
+func TestExplain(t *testing.T) {
+	testCases := []struct {
+		desc string
+		args []string
+		want string
+	}{
+		{
+			desc: "consumed-flags-and-unused",
+			args: []string{"prog", "greet", "world", "--color", "red", "--loud", "yes", "extra"},
+			want: `Explaining [greet]
+cmd:
+  "echo" -> ["echo"]
+args:
+  "{{ arg 0 }}" -> ["world"] (consumed user args 0:"world")
+  "{{ flagValue \"color\" }}" -> ["--color=red"]
+flags:
+  "--color={{ .flag }}" -> ["--color=red"] --color=red placed by flagValue
+  "--loud={{ .flag }}" -> ["--loud=yes"] --loud=yes implicit, appended
+unused args:
+  ["extra"] (not consumed by a template, appended)
+result:
+  ["echo" "world" "--color=red" "--loud=yes" "extra"]
+`,
+		},
+		{
+			desc: "help-reinserted",
+			args: []string{"prog", "greet-all", "--help"},
+			want: `Explaining [greet-all]
+cmd:
+  "echo" -> ["echo"]
+args:
+  "{{ swallowargs }}" -> []
+help:
+  ["--help"] reinserted at position 1
+result:
+  ["echo" "--help"]
+`,
+		},
+		{
+			desc: "swallowed-args",
+			args: []string{"prog", "greet-all", "a", "b"},
+			want: `Explaining [greet-all]
+cmd:
+  "echo" -> ["echo"]
+args:
+  "{{ swallowargs }}" -> [] (consumed user args 0:"a", 1:"b")
+result:
+  ["echo"]
+`,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			testFs := fstest.MapFS{}
+			testFs[config.ConfigFileName] = &fstest.MapFile{Data: []byte(explainConfig)}
+
+			out := &bytes.Buffer{}
+			s, err := New(testFs, Explain(true), Out(out))
+			require.NoError(t, err)
+			root, err := s.ConstructCommandTree(&cobra.Command{Use: "root"}, false)
+			require.NoError(t, err)
+
+			s.Configure(Args(tC.args...))
+			s.SetupRunArgs(root)
+			_, err = executeCommand(root)
+			require.NoError(t, err)
+
+			assert.Equal(t, tC.want, out.String())
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package summon
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"text/template"
+
+	"github.com/BurntSushi/toml"
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v3"
+)
+
+// asset returns the raw content of an embedded asset. The path is relative to
+// the asset root and can be an alias.
+func (d *Driver) asset(assetPath string) (string, error) {
+	filename := path.Join(d.baseDataDir, d.resolveAlias(filepath.ToSlash(filepath.Clean(assetPath))))
+	content, err := fs.ReadFile(d.fs, filename)
+	if err != nil {
+		return "", fmt.Errorf("could not read asset %s: %w", assetPath, err)
+	}
+	return string(content), nil
+}
+
+// readFile returns the content of a local file.
+func readFile(filename string) (string, error) {
+	content, err := afero.ReadFile(appFs, filename)
+	if err != nil {
+		return "", fmt.Errorf("could not read file %s: %w", filename, err)
+	}
+	return string(content), nil
+}
+
+// fileExists returns true if the local file or directory exists.
+func fileExists(filename string) (bool, error) {
+	return afero.Exists(appFs, filename)
+}
+
+// globFiles returns the local files matching pattern, in the filepath.Match
+// syntax, in lexical order.
+func globFiles(pattern string) ([]string, error) {
+	matches, err := afero.Glob(appFs, pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid glob %q: %w", pattern, err)
+	}
+	if matches == nil {
+		matches = []string{}
+	}
+	return matches, nil
+}
+
+// fromFormat returns a template function parsing its argument with unmarshal.
+func fromFormat(format string, unmarshal func([]byte, any) error) func(string) (interface{}, error) {
+	return func(content string) (interface{}, error) {
+		var parsed interface{}
+		err := unmarshal([]byte(content), &parsed)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse %s: %w", format, err)
+		}
+		return parsed, nil
+	}
+}
+
+// fileFuncs are the template functions reading and parsing files.
+func fileFuncs(d *Driver) template.FuncMap {
+	return template.FuncMap{
+		"asset":      d.asset,
+		"readFile":   readFile,
+		"fileExists": fileExists,
+		"glob":       globFiles,
+		"fromJSON":   fromFormat("json", json.Unmarshal),
+		"fromYAML":   fromFormat("yaml", yaml.Unmarshal),
+		"fromTOML":   fromFormat("toml", toml.Unmarshal),
+	}
+}
@@ -0,0 +1,58 @@
+package summon
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/davidovich/summon/internal/testutil"
+	"github.com/davidovich/summon/pkg/config"
+)
+
+func TestFileFunctions(t *testing.T) {
+	defer testutil.ReplaceFs()()
+
+	testFs := fstest.MapFS{}
+	testFs["assets/"+config.ConfigFileName] = &fstest.MapFile{Data: []byte("aliases: {hdr: partials/header.txt}\n")}
+	testFs["assets/partials/header.txt"] = &fstest.MapFile{Data: []byte("# {{ not rendered }}")}
+	s, err := New(testFs)
+	require.NoError(t, err)
+
+	require.NoError(t, afero.WriteFile(appFs, "go.mod", []byte("module example.com/m\n"), 0o644))
+	require.NoError(t, afero.WriteFile(appFs, "package.json", []byte(`{"name": "app", "version": "1.2.3"}`), 0o644))
+	require.NoError(t, afero.WriteFile(appFs, "deploy.yaml", []byte("replicas: 3\nregions: [us, eu]\n"), 0o644))
+	require.NoError(t, afero.WriteFile(appFs, "Cargo.toml", []byte("[package]\nname = \"crate\"\n"), 0o644))
+
+	tests := []struct {
+		name     string
+		tmpl     string
+		expected string
+		err      string
+	}{
+		{name: "asset", tmpl: `{{ asset "partials/header.txt" }}`, expected: "# {{ not rendered }}"},
+		{name: "asset-alias", tmpl: `{{ asset "hdr" }}`, expected: "# {{ not rendered }}"},
+		{name: "asset-missing", tmpl: `{{ asset "nope.txt" }}`, err: "could not read asset nope.txt"},
+		{name: "readFile", tmpl: `{{ readFile "go.mod" | trim }}`, expected: "module example.com/m"},
+		{name: "readFile-missing", tmpl: `{{ readFile "nope" }}`, err: "could not read file nope"},
+		{name: "fromJSON", tmpl: `{{ (readFile "package.json" | fromJSON).version }}`, expected: "1.2.3"},
+		{name: "fromYAML", tmpl: `{{ $d := readFile "deploy.yaml" | fromYAML }}{{ $d.replicas }} {{ index $d.regions 1 }}`, expected: "3 eu"},
+		{name: "fromTOML", tmpl: `{{ (readFile "Cargo.toml" | fromTOML).package.name }}`, expected: "crate"},
+		{name: "fromJSON-invalid", tmpl: `{{ fromJSON "{" }}`, err: "could not parse json"},
+		{name: "fileExists", tmpl: `{{ fileExists "go.mod" }} {{ fileExists "nope" }}`, expected: "true false"},
+		{name: "glob", tmpl: `{{ glob "*.json" }} {{ glob "*.nope" }}`, expected: "[package.json] []"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.renderTemplate(tt.tmpl)
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
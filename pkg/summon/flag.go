@@ -1,6 +1,9 @@
 package summon
 
 import (
+	"maps"
+	"strings"
+
 	"github.com/davidovich/summon/pkg/config"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
@@ -13,8 +16,9 @@ type flagValue struct {
 	userValue     string
 	rendered      string
 	explicit      bool
+	secret        bool
 	initializing  bool
-	wasRenderedFn func()
+	wasRenderedFn func(d *Driver)
 }
 
 func (f *flagValue) Set(s string) error {
@@ -55,13 +59,20 @@ func (f *flagValue) renderTemplate() (string, error) {
 	if f.rendered != "" {
 		return f.rendered, nil
 	}
+	data := maps.Clone(f.d.opts.data)
+	data["flag"] = f.userValue
 	var err error
-	f.d.opts.data["flag"] = f.userValue
-	f.rendered, err = f.d.renderTemplate(f.effect)
+	f.rendered, err = f.d.renderTemplateData(f.effect, data)
+	if f.secret && f.d.inv.secrets != nil {
+		f.d.inv.secrets.add(f.userValue)
+		// mask the whole effect if it transforms the value
+		if !strings.Contains(f.rendered, f.userValue) {
+			f.d.inv.secrets.add(f.rendered)
+		}
+	}
 	if f.wasRenderedFn != nil {
-		f.wasRenderedFn()
+		f.wasRenderedFn(f.d)
 	}
-	delete(f.d.opts.data, "flag")
 	return f.rendered, err
 }
 
@@ -71,12 +82,13 @@ func (d *Driver) AddFlags(cmd *cobra.Command, flags config.Flags, global bool) {
 	}
 }
 
-func (d *Driver) AddFlag(cmd *cobra.Command, name string, flagSpec *config.FlagSpec, global bool, callback func()) *flagValue {
+func (d *Driver) AddFlag(cmd *cobra.Command, name string, flagSpec *config.FlagSpec, global bool, callback func(d *Driver)) *flagValue {
 	v := &flagValue{
 		name:          name,
 		d:             d,
 		effect:        flagSpec.Effect,
 		explicit:      flagSpec.Explicit,
+		secret:        flagSpec.Secret,
 		wasRenderedFn: callback,
 	}
 	var flag *pflag.Flag
@@ -0,0 +1,30 @@
+package summon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchGlob(t *testing.T) {
+	testCases := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{"*.go", "src/a/main.go", true},
+		{"src/*.go", "src/main.go", true},
+		{"src/*.go", "src/a/main.go", false},
+		{"src/**/*.go", "src/main.go", true},
+		{"src/**/*.go", "src/a/b/main.go", true},
+		{"src/**/*.go", "other/main.go", false},
+		{"**", "any/thing", true},
+		{".git/**", ".git/", true},
+		{"node_modules", "a/node_modules/", true},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.pattern+"~"+tC.name, func(t *testing.T) {
+			assert.Equal(t, tC.want, matchGlob(tC.pattern, tC.name))
+		})
+	}
+}
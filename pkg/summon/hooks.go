@@ -0,0 +1,161 @@
+package summon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/anmitsu/go-shlex"
+
+	"github.com/davidovich/summon/pkg/config"
+)
+
+const (
+	hookBefore  = "before"
+	hookAfter   = "after"
+	hookOnError = "onError"
+)
+
+// hooks are the merged global and handle hooks. Handle hooks run inside the
+// global hooks, which only run around the invoked handle, not around run
+// template function calls or hooks.
+type hooks struct {
+	before  config.ArgSliceSpec
+	after   config.ArgSliceSpec
+	onError config.ArgSliceSpec
+}
+
+func (d *Driver) hooksOf(cmdSpec *commandSpec) hooks {
+	global := d.config.Exec.Hooks
+	if d.inv.nested {
+		global = config.HooksSpec{}
+	}
+	return hooks{
+		before:  append(append(config.ArgSliceSpec{}, global.Before...), cmdSpec.hooks.Before...),
+		after:   append(append(config.ArgSliceSpec{}, cmdSpec.hooks.After...), global.After...),
+		onError: append(append(config.ArgSliceSpec{}, cmdSpec.hooks.OnError...), global.OnError...),
+	}
+}
+
+func (h hooks) empty() bool {
+	return len(h.before) == 0 && len(h.after) == 0 && len(h.onError) == 0
+}
+
+// runHooks runs the hooks of kind in order and stops at the first failure. It
+// returns the plans of the hooks in dry-run.
+func (d *Driver) runHooks(kind, ref string, hooks config.ArgSliceSpec) ([]*Plan, error) {
+	var plans []*Plan
+	for _, h := range hooks {
+		var plan *Plan
+		var err error
+		switch hook := h.(type) {
+		case string:
+			plan, err = d.runHandleHook(hook)
+		case []interface{}:
+			plan, err = d.runInlineHook(kind, ref, hook)
+		default:
+			err = fmt.Errorf("unhandled type %T, use a handle reference or a command list", h)
+		}
+		if plan != nil {
+			plans = append(plans, plan)
+		}
+		if err != nil {
+			return plans, fmt.Errorf("%s hook of [%s] failed: %w", kind, ref, err)
+		}
+	}
+	return plans, nil
+}
+
+// runHandleHook runs a hook referencing a handle, followed by its args.
+func (d *Driver) runHandleHook(hook string) (*Plan, error) {
+	rendered, err := d.renderTemplate(hook)
+	if err != nil {
+		return nil, err
+	}
+	args, err := shlex.Split(rendered, true)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("empty handle reference %q", hook)
+	}
+
+	hookDriver := d.nestedDriver()
+	err = hookDriver.run(Ref(args[0]), Args(args[1:]...), Out(d.opts.out))
+	return hookDriver.inv.lastPlan, err
+}
+
+// runInlineHook runs a hook holding a command.
+func (d *Driver) runInlineHook(kind, ref string, hook []interface{}) (*Plan, error) {
+	argv, err := d.RenderArgs(FlattenStrings(hook)...)
+	if err != nil {
+		return nil, err
+	}
+	if len(argv) == 0 {
+		return nil, fmt.Errorf("empty command")
+	}
+	cmd := d.execCommand(argv[0], argv[1:]...)
+
+	if d.opts.dryrun {
+		if d.opts.dryRunFormat == dryRunText {
+			fmt.Fprintf(d.stderr(), "Would execute %s hook [%s] -> `%s`...\n", kind, ref, d.mask(cmd.String()))
+		}
+		dir, _ := os.Getwd()
+		return &Plan{Handle: ref + " " + kind, Argv: d.maskAll(argv), Dir: dir}, nil
+	}
+	if d.opts.debug {
+		fmt.Fprintf(d.stderr(), "Executing %s hook [%s] -> `%s`...\n", kind, ref, d.mask(cmd.String()))
+	}
+	cmd.Stdin = d.stdin()
+	cmd.Stdout = d.opts.out
+	cmd.Stderr = d.stderr()
+	return nil, d.runCmd(cmd)
+}
+
+// setRunResult makes the exit code and duration of a command available to
+// the after and onError hooks, and in the Result of the invocation.
+func (d *Driver) setRunResult(runErr error, duration time.Duration) {
+	exitCode := 0
+	if runErr != nil {
+		exitCode = 1
+		var exitErr *exec.ExitError
+		if errors.As(runErr, &exitErr) {
+			exitCode = exitErr.ExitCode()
+		}
+	}
+	d.opts.data["exitCode"] = exitCode
+	d.opts.data["duration"] = duration
+	d.inv.result.ExitCode = exitCode
+	d.inv.result.Duration = duration
+}
+
+// runWithHooks runs the before hooks, then run, then the after hooks if
+// everything succeeded or the onError hooks otherwise. The error of run (or
+// of a before hook) is returned, errors of onError hooks are reported on
+// stderr. The after and onError hooks are not run when ctx is done, like when
+// a watched run is killed to be restarted.
+func (d *Driver) runWithHooks(ctx context.Context, ref string, h hooks, run func() error) error {
+	_, err := d.runHooks(hookBefore, ref, h.before)
+	var duration time.Duration
+	if err == nil {
+		start := time.Now()
+		err = run()
+		duration = time.Since(start)
+	}
+	d.setRunResult(err, duration)
+	if ctx.Err() != nil {
+		return err
+	}
+
+	if err == nil {
+		_, err = d.runHooks(hookAfter, ref, h.after)
+		return err
+	}
+	if _, hookErr := d.runHooks(hookOnError, ref, h.onError); hookErr != nil {
+		fmt.Fprintln(d.stderr(), d.maskError(hookErr))
+	}
+	return err
+}
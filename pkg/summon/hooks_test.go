@@ -0,0 +1,130 @@
+package summon
+
+import (
+	"bytes"
+	"encoding/json"
+	"os/exec"
+	"testing"
+	"testing/fstest"
+
+	"github.com/lithammer/dedent"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/davidovich/summon/pkg/config"
+)
+
+var hooksConfig = dedent.Dedent(`
+	exec:
+	  hooks:
+	    before: [[echo, global-before]]
+	    after: [[echo, global-after]]
+	  handles:
+	    login: [echo, login]
+	    push:
+	      cmd: [echo, push]
+	      before: ['login {{ "registry" }}']
+	      after: [[echo, 'after {{ .exitCode }}']]
+	      onError: [[echo, 'onError {{ .exitCode }}']]
+	    fail:
+	      cmd: [sh, -c, 'exit 3']
+	      after: [[echo, after]]
+	      onError: [[echo, 'onError {{ .exitCode }}']]
+	    fail-before:
+	      cmd: [echo, not run]
+	      before: [[sh, -c, 'exit 2']]
+	      onError: [[echo, 'onError {{ .exitCode }}']]
+	`)
+
+func makeHooksDriver(t *testing.T, opts ...Option) *Driver {
+	testFs := fstest.MapFS{}
+	testFs[config.ConfigFileName] = &fstest.MapFile{Data: []byte(hooksConfig)}
+
+	s, err := New(testFs, opts...)
+	require.NoError(t, err)
+	_, err = s.ConstructCommandTree(&cobra.Command{Use: "root"}, false)
+	require.NoError(t, err)
+	return s
+}
+
+func TestHooks(t *testing.T) {
+	testCases := []struct {
+		handle   string
+		wantOut  string
+		wantCode int
+	}{
+		{
+			handle:  "push",
+			wantOut: "global-before\nlogin registry\npush\nafter 0\nglobal-after\n",
+		},
+		{
+			handle:   "fail",
+			wantOut:  "global-before\nonError 3\n",
+			wantCode: 3,
+		},
+		{
+			handle:   "fail-before",
+			wantOut:  "global-before\nonError 2\n",
+			wantCode: 2,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.handle, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			s := makeHooksDriver(t, Out(out), Exec(true))
+
+			err := s.Run(Ref(tC.handle))
+			assert.Equal(t, tC.wantOut, out.String())
+			if tC.wantCode == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			var exitErr *exec.ExitError
+			require.ErrorAs(t, err, &exitErr)
+			assert.Equal(t, tC.wantCode, exitErr.ExitCode())
+		})
+	}
+}
+
+func TestHooksDryRun(t *testing.T) {
+	out := &bytes.Buffer{}
+	s := makeHooksDriver(t, DryRunFormat("json"), Out(out))
+
+	require.NoError(t, s.Run(Ref("push")))
+
+	plan := Plan{}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &plan))
+	argv := func(plans []*Plan) [][]string {
+		var a [][]string
+		for _, p := range plans {
+			a = append(a, p.Argv)
+		}
+		return a
+	}
+	assert.Equal(t, [][]string{{"echo", "global-before"}, {"echo", "login", "registry"}}, argv(plan.Before))
+	assert.Equal(t, [][]string{{"echo", "after 0"}, {"echo", "global-after"}}, argv(plan.After))
+	assert.Equal(t, [][]string{{"echo", "onError 0"}}, argv(plan.OnError))
+}
+
+func TestHooksDryRunSh(t *testing.T) {
+	testCases := []struct {
+		handle   string
+		expected string
+	}{
+		{handle: "push", expected: "global-before\nlogin registry\npush\nafter 0\nglobal-after\n"},
+		// the exit code of the onError hooks cannot be known in advance
+		{handle: "fail", expected: "global-before\nonError 0\n"},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.handle, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			s := makeHooksDriver(t, DryRunFormat("sh"), Out(out))
+
+			require.NoError(t, s.Run(Ref(tC.handle)))
+
+			got, _ := exec.Command("sh", "-c", out.String()).Output()
+			assert.Equal(t, tC.expected, string(got), out.String())
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package summon
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+)
+
+// includeError is an error of an included asset, with the chain of includes
+// that led to it.
+type includeError struct {
+	chain []string
+	err   error
+}
+
+func (e *includeError) Error() string {
+	return fmt.Sprintf("include %s: %s", strings.Join(e.chain, " -> "), e.err)
+}
+
+func (e *includeError) Unwrap() error {
+	return e.err
+}
+
+// include renders the embedded asset name with data, or with the data of the
+// invocation when it is omitted. Names starting with ./ or ../ are relative to
+// the including asset, other names are relative to the asset root and can be
+// aliases.
+func (d *Driver) include(name string, data ...interface{}) (string, error) {
+	assetPath := d.resolveAlias(path.Clean(name))
+	if (strings.HasPrefix(name, "./") || strings.HasPrefix(name, "../")) && len(d.inv.includes) != 0 {
+		assetPath = path.Join(path.Dir(d.inv.includes[len(d.inv.includes)-1]), name)
+	}
+	chain := append(slices.Clone(d.inv.includes), assetPath)
+	if slices.Contains(d.inv.includes, assetPath) {
+		return "", &includeError{chain: chain, err: errors.New("include cycle")}
+	}
+	content, err := fs.ReadFile(d.fs, path.Join(d.baseDataDir, assetPath))
+	if err != nil {
+		return "", &includeError{chain: chain, err: err}
+	}
+
+	rule, err := d.renderRule(assetPath)
+	if err != nil {
+		return "", &includeError{chain: chain, err: err}
+	}
+	if rule.Raw {
+		return string(content), nil
+	}
+
+	includes := d.inv.includes
+	d.inv.includes = chain
+	defer func() { d.inv.includes = includes }()
+
+	var tmplData interface{} = d.opts.data
+	if len(data) != 0 {
+		tmplData = data[0]
+	}
+	rendered, err := d.renderAsset(assetPath, string(content), tmplData, rule.Delims)
+	if err != nil {
+		// report the innermost include, which has the whole chain
+		var incErr *includeError
+		if errors.As(err, &incErr) {
+			return "", incErr
+		}
+		return "", &includeError{chain: chain, err: err}
+	}
+	return rendered, nil
+}
+
+// renderAsset renders the content of an asset in a template named after it,
+// using delims when they are set.
+func (d *Driver) renderAsset(assetPath, content string, data interface{}, delims []string) (string, error) {
+	t, err := d.prepareTemplate()
+	if err != nil {
+		return "", err
+	}
+	t = t.New(assetPath).Option(d.missingKey())
+	if len(delims) != 0 {
+		t.Delims(delims[0], delims[1])
+	}
+	t, err = parseTemplate(t, content)
+	if err != nil {
+		return "", err
+	}
+	return executeTemplate(t, data)
+}
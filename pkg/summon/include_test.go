@@ -0,0 +1,47 @@
+package summon
+
+import (
+	"bytes"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInclude(t *testing.T) {
+	testFs := fstest.MapFS{
+		"assets/partials/header.tpl": &fstest.MapFile{Data: []byte("# {{ .title }}")},
+		"assets/ci/pipeline.yml":     &fstest.MapFile{Data: []byte(`{{ include "partials/header.tpl" (dict "title" "CI") }}` + "\n" + `{{ include "./steps.tpl" . }}`)},
+		"assets/ci/steps.tpl":        &fstest.MapFile{Data: []byte(`steps for {{ .name }}`)},
+		"assets/cycle/a.tpl":         &fstest.MapFile{Data: []byte(`{{ include "./b.tpl" . }}`)},
+		"assets/cycle/b.tpl":         &fstest.MapFile{Data: []byte(`{{ include "cycle/a.tpl" . }}`)},
+		"assets/broken.tpl":          &fstest.MapFile{Data: []byte(`{{ include "partials/nope.tpl" }}`)},
+	}
+	name := `{"name": "build"}`
+	s, err := New(testFs, JSON(&name))
+	require.NoError(t, err)
+
+	t.Run("asset", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		_, err := s.Summon(Filename("ci/pipeline.yml"), Dest("-"), Out(out))
+		require.NoError(t, err)
+		assert.Equal(t, "# CI\nsteps for build", out.String())
+	})
+
+	t.Run("handle-template", func(t *testing.T) {
+		rendered, err := s.renderTemplate(`{{ include "partials/header.tpl" (dict "title" "T") }}`)
+		require.NoError(t, err)
+		assert.Equal(t, "# T", rendered)
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		_, err := s.Summon(Filename("cycle/a.tpl"), Dest("-"), Out(&bytes.Buffer{}))
+		assert.ErrorContains(t, err, "include cycle/a.tpl -> cycle/b.tpl -> cycle/a.tpl: include cycle")
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		_, err := s.Summon(Filename("broken.tpl"), Dest("-"), Out(&bytes.Buffer{}))
+		assert.ErrorContains(t, err, "include broken.tpl -> partials/nope.tpl: open assets/partials/nope.tpl")
+	})
+}
@@ -43,3 +43,9 @@ type Prompter interface {
 	Choose(choices []string) (string, error)
 	Input(defaultVal string) (string, error)
 }
+
+// ToolManager allows listing and pruning the pinned go tools of handles.
+type ToolManager interface {
+	Tools() ([]Tool, error)
+	PruneTools(all bool) ([]string, error)
+}
@@ -17,14 +17,20 @@ func (d *Driver) List(opts ...Option) ([]string, error) {
 		if path == d.baseDataDir {
 			return nil
 		}
+		rel, err := filepath.Rel(d.baseDataDir, path)
+		if err != nil {
+			return err
+		}
+		if d.isPartial(filepath.ToSlash(rel)) {
+			if de.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
 
 		// old packr.Box List would only produce actual entries, and not intermediate
 		// entries, simulate that by ignoring dir only entries.
 		if !de.IsDir() {
-			rel, err := filepath.Rel(d.baseDataDir, path)
-			if err != nil {
-				return err
-			}
 			list = append(list, rel)
 		}
 		return nil
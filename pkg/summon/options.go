@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"slices"
 
 	"github.com/spf13/cobra"
 
@@ -14,6 +15,9 @@ import (
 // MainOptions are used to configure summon at build time
 type MainOptions struct {
 	WithoutRunSubcmd bool
+	// ExecMiddlewares wrap the execution of all commands, see
+	// WithExecMiddleware
+	ExecMiddlewares []func(next Executor) Executor
 }
 
 // options for all summon commands
@@ -36,20 +40,43 @@ type options struct {
 	initialArgs []string
 	// help wanted is the position of --help or -h request
 	helpWanted helpInfo
-	// keep track of arg indexes that were used
-	argsConsumed map[int]struct{}
 	// template rendering data
 	data map[string]interface{}
 	// out
 	out io.Writer
 	// raw disables template rendering
 	raw bool
+	// strict makes templates fail on missing keys
+	strict bool
 	// debug enables printing debug info
 	debug bool
 	// dryrun disables any command execution
 	dryrun bool
+	// dryRunFormat is the output format of dryrun
+	dryRunFormat string
+	// exec replaces the summon process with the command
+	exec bool
+	// explain traces how the command line is built instead of running it
+	explain bool
+	// watch are the globs of files that re-run the command when they change
+	watch []string
+	// watchIgnore are the globs of files that are not watched
+	watchIgnore []string
+	// yes answers yes to confirmations
+	yes bool
+	// in is the stdin of the command, os.Stdin if nil
+	in io.Reader
+	// errOut receives the stderr of the command, os.Stderr if nil
+	errOut io.Writer
+	// capture keeps the output of the command in the Result
+	capture bool
+	// quietStderr only keeps the stderr of run template function calls for
+	// their errors
+	quietStderr bool
 	// execCommand overrides the command used to run external processes
 	execCommand command.ExecCommandFn
+	// execMiddlewares wrap the execution of commands
+	execMiddlewares []func(next Executor) Executor
 	//prompter
 	prompter Prompter
 }
@@ -107,6 +134,85 @@ func Debug(enable bool) Option {
 func DryRun(enable bool) Option {
 	return func(opts *options) error {
 		opts.dryrun = enable
+		if opts.dryRunFormat == "" {
+			opts.dryRunFormat = dryRunText
+		}
+		return nil
+	}
+}
+
+// DryRunFormat does not run the command and outputs what would be executed
+// in format: text (on stderr), json (the plan) or sh (a shell script
+// reproducing the invocation).
+func DryRunFormat(format string) Option {
+	return func(opts *options) error {
+		switch format {
+		case dryRunText, dryRunJSON, dryRunSh:
+		default:
+			return fmt.Errorf("unknown dry-run format %q, use text, json or sh", format)
+		}
+		opts.dryrun = true
+		opts.dryRunFormat = format
+		return nil
+	}
+}
+
+// Exec replaces the summon process with the executed command instead of
+// starting a child process. When the output of the command is captured,
+// a child process is used.
+func Exec(enable bool) Option {
+	return func(opts *options) error {
+		opts.exec = enable
+		return nil
+	}
+}
+
+// Explain outputs how the command line of a handle is built from its
+// config and the user args, without running it.
+func Explain(enable bool) Option {
+	return func(opts *options) error {
+		opts.explain = enable
+		if enable {
+			opts.dryrun = true
+			if opts.dryRunFormat == "" {
+				opts.dryRunFormat = dryRunText
+			}
+		}
+		return nil
+	}
+}
+
+// Watch re-runs the command when files matching globs change, killing the
+// previous run if it is still alive.
+func Watch(globs ...string) Option {
+	return func(opts *options) error {
+		opts.watch = globs
+		return nil
+	}
+}
+
+// WatchIgnore excludes files matching globs from the watched files.
+func WatchIgnore(globs ...string) Option {
+	return func(opts *options) error {
+		opts.watchIgnore = globs
+		return nil
+	}
+}
+
+// Yes bypasses the confirmation of handles declaring confirm or
+// confirmTyping.
+func Yes(yes bool) Option {
+	return func(opts *options) error {
+		opts.yes = yes
+		return nil
+	}
+}
+
+// QuietStderr silences the stderr of handles called by run template
+// functions. It is then only shown in the error of a failed run call.
+func QuietStderr(enable bool) Option {
+	return func(opts *options) error {
+		opts.quietStderr = enable
 		return nil
 	}
 }
@@ -127,6 +233,15 @@ func Raw(raw bool) Option {
 	}
 }
 
+// Strict makes templates fail on missing keys instead of rendering an empty
+// string. It can also be enabled with the strict config setting.
+func Strict(strict bool) Option {
+	return func(opts *options) error {
+		opts.strict = strict
+		return nil
+	}
+}
+
 // Dest specifies where the file(s) will be rooted.
 // '-' is a special value representing stdout.
 func Dest(dest string) Option {
@@ -146,6 +261,33 @@ func Out(w io.Writer) Option {
 	}
 }
 
+// In sets the stdin of the executed commands, os.Stdin by default. The stdin
+// declared by a handle takes precedence.
+func In(r io.Reader) Option {
+	return func(opts *options) error {
+		opts.in = r
+		return nil
+	}
+}
+
+// Err sets where the stderr of the executed commands, and the diagnostics of
+// the run (like --debug and --dry-run), are written, os.Stderr by default.
+func Err(w io.Writer) Option {
+	return func(opts *options) error {
+		opts.errOut = w
+		return nil
+	}
+}
+
+// Capture keeps the stdout and stderr of the handle command in the Result of
+// RunContext instead of writing them to Out and Err.
+func Capture(capture bool) Option {
+	return func(opts *options) error {
+		opts.capture = capture
+		return nil
+	}
+}
+
 // ShowTree will print a pretty graph of the data tree.
 func ShowTree(tree bool) Option {
 	return func(opts *options) error {
@@ -184,6 +326,19 @@ func ExecCmd(e command.ExecCommandFn) Option {
 	}
 }
 
+// WithExecMiddleware adds middlewares around the execution of every command,
+// including the ones of run template function calls, hooks and completions.
+// A middleware can act before and after calling next, change the command
+// (like prefixing it with nice) or refuse to run it by returning an error
+// without calling next. The first middleware added is the outermost.
+func WithExecMiddleware(middlewares ...func(next Executor) Executor) Option {
+	return func(opts *options) error {
+		// clip so that invocations never share the appended middlewares
+		opts.execMiddlewares = append(slices.Clip(opts.execMiddlewares), middlewares...)
+		return nil
+	}
+}
+
 // DefaultsFrom sets options from user config.
 func (o *options) DefaultsFrom(conf config.Config) {
 	if conf.OutputDir != "" {
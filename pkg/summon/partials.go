@@ -0,0 +1,65 @@
+package summon
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+	"text/template"
+
+	"github.com/Masterminds/sprig/v3"
+
+	"github.com/davidovich/summon/pkg/config"
+)
+
+// partialsGlob returns the glob of the template partials assets.
+func (d *Driver) partialsGlob() string {
+	if d.config.Partials != "" {
+		return d.config.Partials
+	}
+	return config.DefaultPartials
+}
+
+// loadPartials parses the template partials assets in the template context,
+// so the templates they define can be used by handles and assets. Each
+// partial is also a template named after its asset path.
+func (d *Driver) loadPartials() error {
+	matches, err := fs.Glob(d.fs, path.Join(d.baseDataDir, d.partialsGlob()))
+	if err != nil {
+		return fmt.Errorf("invalid partials glob %q in config %s: %w", d.partialsGlob(), config.ConfigFileName, err)
+	}
+	if len(matches) == 0 {
+		return nil
+	}
+	if d.templateCtx == nil {
+		d.templateCtx = template.New(Name).
+			Option("missingkey=zero").
+			Funcs(sprig.TxtFuncMap()).
+			Funcs(summonFuncMap(d))
+	}
+	for _, m := range matches {
+		content, err := fs.ReadFile(d.fs, m)
+		if err != nil {
+			return err
+		}
+		name := strings.TrimPrefix(m, d.baseDataDir+"/")
+		_, err = d.templateCtx.New(name).Parse(string(content))
+		if err != nil {
+			return fmt.Errorf("could not parse template partial %s: %w", name, err)
+		}
+	}
+	lenientRequired(d.templateCtx)
+	return nil
+}
+
+// isPartial returns true if the asset path is in the directory of the
+// template partials, or matches their glob if they are at the asset root.
+func (d *Driver) isPartial(assetPath string) bool {
+	glob := d.partialsGlob()
+	dir := path.Dir(glob)
+	if dir == "." {
+		matched, _ := path.Match(glob, assetPath)
+		return matched
+	}
+	return assetPath == dir || strings.HasPrefix(assetPath, dir+"/")
+}
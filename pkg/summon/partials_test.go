@@ -0,0 +1,81 @@
+package summon
+
+import (
+	"bytes"
+	"testing"
+	"testing/fstest"
+
+	"github.com/lithammer/dedent"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/davidovich/summon/internal/testutil"
+	"github.com/davidovich/summon/pkg/config"
+)
+
+func TestPartials(t *testing.T) {
+	defer testutil.ReplaceFs()()
+
+	testFs := fstest.MapFS{
+		"assets/" + config.ConfigFileName: &fstest.MapFile{Data: []byte(dedent.Dedent(`
+			exec:
+			  handles:
+			    greet:
+			      cmd: [echo, '{{ template "greeting" "handle" }}']
+			`))},
+		"assets/_templates/helpers.tpl": &fstest.MapFile{Data: []byte(`{{ define "greeting" }}hello {{ . }}{{ end }}`)},
+		"assets/_templates/notes.txt":   &fstest.MapFile{Data: []byte("not a partial, but in their directory")},
+		"assets/hello.txt":              &fstest.MapFile{Data: []byte(`{{ template "greeting" "asset" }}`)},
+	}
+	s, err := New(testFs)
+	require.NoError(t, err)
+
+	t.Run("handle", func(t *testing.T) {
+		plan, err := s.Plan(Ref("greet"))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"echo", "hello handle"}, plan.Argv)
+	})
+
+	t.Run("asset", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		_, err := s.Summon(Filename("hello.txt"), Dest("-"), Out(out))
+		require.NoError(t, err)
+		assert.Equal(t, "hello asset", out.String())
+	})
+
+	t.Run("not-listed", func(t *testing.T) {
+		list, err := s.List()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"hello.txt", config.ConfigFileName}, list)
+	})
+
+	t.Run("not-summoned", func(t *testing.T) {
+		_, err := s.Summon(Filename("_templates/helpers.tpl"))
+		assert.ErrorContains(t, err, "_templates/helpers.tpl is a template partial and cannot be summoned")
+
+		_, err = s.Summon(All(true), Filename(""), Dest("out"))
+		require.NoError(t, err)
+		exists, _ := afero.Exists(appFs, "out/hello.txt")
+		assert.True(t, exists)
+		exists, _ = afero.DirExists(appFs, "out/_templates")
+		assert.False(t, exists)
+	})
+
+	t.Run("configured-glob", func(t *testing.T) {
+		testFs := fstest.MapFS{
+			"assets/" + config.ConfigFileName: &fstest.MapFile{Data: []byte("partials: 'lib/*.gotmpl'\n")},
+			"assets/lib/a.gotmpl":             &fstest.MapFile{Data: []byte(`{{ define "a" }}A{{ end }}`)},
+			"assets/lib/broken.tpl":           &fstest.MapFile{Data: []byte(`{{ define "broken" }}`)},
+		}
+		s, err := New(testFs)
+		require.NoError(t, err)
+		rendered, err := s.renderTemplate(`{{ template "a" }}`)
+		require.NoError(t, err)
+		assert.Equal(t, "A", rendered)
+
+		testFs["assets/lib/broken.gotmpl"] = &fstest.MapFile{Data: []byte(`{{ define "broken" }}`)}
+		_, err = New(testFs)
+		assert.ErrorContains(t, err, "could not parse template partial lib/broken.gotmpl")
+	})
+}
@@ -0,0 +1,337 @@
+package summon
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/afero"
+
+	"github.com/davidovich/summon/pkg/command"
+)
+
+// dry-run output formats
+const (
+	dryRunText = "text"
+	dryRunJSON = "json"
+	dryRunSh   = "sh"
+)
+
+// Plan describes what a handle invocation would execute. It is returned by
+// Driver.Plan and written by --dry-run=json.
+type Plan struct {
+	// Handle is the invoked handle
+	Handle string `json:"handle"`
+	// Argv is the command and its arguments
+	Argv []string `json:"argv"`
+	// Env holds the environment set by summon, the rest is inherited
+	Env []string `json:"env,omitempty"`
+	// Dir is the working directory of the command
+	Dir string `json:"dir"`
+	// Stdin is the rendered stdin content, if any
+	Stdin *string `json:"stdin,omitempty"`
+	// Script is the rendered script content, if any
+	Script *string `json:"script,omitempty"`
+	// ConsumedArgs are the user args used by templates
+	ConsumedArgs []string `json:"consumedArgs"`
+	// UnconsumedArgs are the user args appended to the command
+	UnconsumedArgs []string `json:"unconsumedArgs"`
+	// ImplicitFlags are the rendered flags appended to the command
+	ImplicitFlags []string `json:"implicitFlags"`
+	// Nested are the plans of the run template function calls
+	Nested []*Plan `json:"nested,omitempty"`
+	// Before are the plans of the before hooks
+	Before []*Plan `json:"before,omitempty"`
+	// After are the plans of the after hooks
+	After []*Plan `json:"after,omitempty"`
+	// OnError are the plans of the onError hooks
+	OnError []*Plan `json:"onError,omitempty"`
+	// Files are the files written by the summon template function in the
+	// temporary directory of the invocation, which is removed after it
+	Files []PlanFile `json:"files,omitempty"`
+
+	scriptPath   string
+	ephemeralDir string
+}
+
+// PlanFile is a file written by the summon template function.
+type PlanFile struct {
+	// Path is relative to the temporary directory of the invocation
+	Path string `json:"path"`
+	// Content is the rendered content of the file
+	Content string `json:"content"`
+	// Mode is the octal file mode (like "0755")
+	Mode string `json:"mode"`
+}
+
+// Plan renders a handle like Run in dry-run and returns what would be
+// executed, including the run template function calls and the hooks. Nothing
+// is executed and nothing is written to Out. Secrets are masked like in
+// --dry-run.
+func (d *Driver) Plan(opts ...Option) (*Plan, error) {
+	c := d.invoke()
+	err := c.run(append(opts, Explain(false), DryRunFormat(dryRunJSON), Out(io.Discard))...)
+	if err != nil {
+		return nil, err
+	}
+	return c.inv.lastPlan, nil
+}
+
+// newPlan creates the plan of a rendered command.
+func (d *Driver) newPlan(ref string, rendered *renderedCmd, cmd *command.Cmd) *Plan {
+	dir := cmd.Dir
+	if dir == "" {
+		dir, _ = os.Getwd()
+	}
+	maskPtr := func(s *string) *string {
+		if s == nil {
+			return nil
+		}
+		masked := d.mask(*s)
+		return &masked
+	}
+	return &Plan{
+		Handle:         ref,
+		Argv:           d.maskAll(rendered.args),
+		Env:            d.maskAll(rendered.env),
+		Dir:            dir,
+		Stdin:          maskPtr(rendered.stdin),
+		Script:         maskPtr(rendered.script),
+		ConsumedArgs:   d.maskAll(rendered.consumedArgs),
+		UnconsumedArgs: d.maskAll(rendered.unusedArgs),
+		ImplicitFlags:  d.maskAll(rendered.implicitFlags),
+		Nested:         d.inv.nestedPlans,
+		Files:          d.ephemeralFiles(),
+		scriptPath:     rendered.scriptPath,
+		ephemeralDir:   d.inv.ephemeralDir,
+	}
+}
+
+// ephemeralFiles returns the files summoned in the temporary directory of the
+// invocation.
+func (d *Driver) ephemeralFiles() []PlanFile {
+	if d.inv.ephemeralDir == "" {
+		return nil
+	}
+	var files []PlanFile
+	afero.Walk(appFs, d.inv.ephemeralDir, func(p string, info fs.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return nil
+		}
+		content, err := afero.ReadFile(appFs, p)
+		if err != nil {
+			return nil
+		}
+		rel, _ := filepath.Rel(d.inv.ephemeralDir, p)
+		files = append(files, PlanFile{
+			Path:    filepath.ToSlash(rel),
+			Content: d.mask(string(content)),
+			Mode:    fmt.Sprintf("%04o", info.Mode().Perm()),
+		})
+		return nil
+	})
+	return files
+}
+
+// consumedArgs returns the user args that were consumed by a template render.
+func consumedArgs(args []string, consumed map[int]struct{}) []string {
+	indexes := make([]int, 0, len(consumed))
+	for i := range consumed {
+		if i < len(args) {
+			indexes = append(indexes, i)
+		}
+	}
+	sort.Ints(indexes)
+
+	consumedArgs := []string{}
+	for _, i := range indexes {
+		consumedArgs = append(consumedArgs, args[i])
+	}
+	return consumedArgs
+}
+
+// write outputs the plan in the requested format.
+func (p *Plan) write(w io.Writer, format string) error {
+	switch format {
+	case dryRunJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(p)
+	case dryRunSh:
+		sh := &shScript{}
+		lines := p.shLines(sh)
+		if sh.temps != 0 {
+			// all temporary files are removed with one trap
+			lines = append([]string{"summon_tmp=$(mktemp -d)", `trap 'rm -rf "$summon_tmp"' EXIT`}, lines...)
+		}
+		_, err := fmt.Fprintf(w, "#!/bin/sh\n# %s [%s] dry-run\nset -e\ncd %s\n%s\n",
+			Name, p.Handle, shellQuote(p.Dir), strings.Join(lines, "\n"))
+		return err
+	}
+	return fmt.Errorf("unknown dry-run format %q", format)
+}
+
+// runMarker is a placeholder for the output of the nth run call in a dry-run.
+func runMarker(n int) string {
+	return fmt.Sprintf("@@summon-run-%d@@", n)
+}
+
+var runMarkerRe = regexp.MustCompile(`@@summon-run-([0-9]+)@@`)
+
+// shScript is the state of the shell script reproducing a plan and its
+// nested plans.
+type shScript struct {
+	// temps numbers the temporary files of the script
+	temps int
+}
+
+// tempVar returns the variable holding the path of a new temporary file in
+// the $summon_tmp directory of the script.
+func (sh *shScript) tempVar(kind string) (string, string) {
+	sh.temps++
+	name := fmt.Sprintf("summon_%s_%d", kind, sh.temps)
+	return name, fmt.Sprintf(`%s="$summon_tmp/%s"`, name, name)
+}
+
+// shLines renders the shell lines reproducing the plan.
+func (p *Plan) shLines(sh *shScript) []string {
+	var lines []string
+	filesVar := ""
+	if len(p.Files) != 0 {
+		var decl string
+		filesVar, decl = sh.tempVar("files")
+		lines = append(lines, decl)
+		for _, f := range p.Files {
+			file := `"$` + filesVar + `"/` + shellQuote(f.Path)
+			lines = append(lines,
+				fmt.Sprintf(`mkdir -p "$(dirname %s)"`, file),
+				fmt.Sprintf(`printf '%%s' %s > %s`, p.shWord(f.Content, sh, filesVar), file),
+				fmt.Sprintf("chmod %s %s", f.Mode, file),
+			)
+		}
+	}
+	scriptVar := ""
+	if p.Script != nil {
+		var decl string
+		scriptVar, decl = sh.tempVar("script")
+		lines = append(lines,
+			decl,
+			fmt.Sprintf(`printf '%%s' %s > "$%s"`, p.shWord(*p.Script, sh, filesVar), scriptVar),
+		)
+	}
+
+	var cmdLine []string
+	for _, e := range p.Env {
+		name, value, _ := strings.Cut(e, "=")
+		cmdLine = append(cmdLine, name+"="+p.shWord(value, sh, filesVar))
+	}
+	for _, a := range p.Argv {
+		switch {
+		case p.Script != nil && a == p.scriptPath:
+			cmdLine = append(cmdLine, `"$`+scriptVar+`"`)
+		default:
+			cmdLine = append(cmdLine, p.shWord(a, sh, filesVar))
+		}
+	}
+
+	line := strings.Join(cmdLine, " ")
+	if p.Stdin != nil {
+		line = fmt.Sprintf("printf '%%s' %s | %s", p.shWord(*p.Stdin, sh, filesVar), line)
+	}
+
+	var hookLines []string
+	for _, h := range p.Before {
+		hookLines = append(hookLines, h.shLines(sh)...)
+	}
+	lines = append(hookLines, lines...)
+	if len(p.After) == 0 && len(p.OnError) == 0 {
+		return append(lines, line)
+	}
+
+	lines = append(lines, "if "+line+"; then")
+	for _, h := range p.After {
+		lines = append(lines, indent(h.shLines(sh))...)
+	}
+	lines = append(lines, "else", "  summon_status=$?")
+	for _, h := range p.OnError {
+		lines = append(lines, indent(h.shLines(sh))...)
+	}
+	return append(lines, "  exit $summon_status", "fi")
+}
+
+// shWord quotes s, where the temporary directory of the invocation is
+// replaced by the filesVar directory of the script, and the run markers by
+// the command substitution of the nested plans.
+func (p *Plan) shWord(s string, sh *shScript, filesVar string) string {
+	if runMarkerRe.MatchString(s) {
+		return p.shSubstitute(s, sh, filesVar)
+	}
+	if filesVar == "" || !strings.Contains(s, p.ephemeralDir) {
+		return shellQuote(s)
+	}
+	var b strings.Builder
+	for i, part := range strings.Split(s, p.ephemeralDir) {
+		if i != 0 {
+			b.WriteString(`"$` + filesVar + `"`)
+		}
+		if part != "" {
+			b.WriteString(shellQuote(part))
+		}
+	}
+	return b.String()
+}
+
+func indent(lines []string) []string {
+	indented := make([]string, 0, len(lines))
+	for _, l := range lines {
+		indented = append(indented, "  "+l)
+	}
+	return indented
+}
+
+// shSubstitute renders a double quoted argument where the run markers are
+// replaced by the command substitution of the nested plans.
+func (p *Plan) shSubstitute(arg string, sh *shScript, filesVar string) string {
+	dq := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "`", "\\`")
+	literal := func(s string) string {
+		s = dq.Replace(s)
+		if filesVar != "" {
+			s = strings.ReplaceAll(s, dq.Replace(p.ephemeralDir), "${"+filesVar+"}")
+		}
+		return s
+	}
+
+	var b strings.Builder
+	b.WriteString(`"`)
+	last := 0
+	for _, m := range runMarkerRe.FindAllStringSubmatchIndex(arg, -1) {
+		b.WriteString(literal(arg[last:m[0]]))
+		var n int
+		fmt.Sscanf(arg[m[2]:m[3]], "%d", &n)
+		if n < len(p.Nested) && p.Nested[n] != nil {
+			b.WriteString("$(")
+			b.WriteString(strings.Join(p.Nested[n].shLines(sh), "\n"))
+			b.WriteString(")")
+		}
+		last = m[1]
+	}
+	b.WriteString(literal(arg[last:]))
+	b.WriteString(`"`)
+	return b.String()
+}
+
+var shellSafe = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// shellQuote quotes s so it is interpreted literally by a POSIX shell.
+func shellQuote(s string) string {
+	if shellSafe.MatchString(s) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
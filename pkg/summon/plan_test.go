@@ -0,0 +1,200 @@
+package summon
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/lithammer/dedent"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/davidovich/summon/pkg/config"
+)
+
+var planConfig = dedent.Dedent(`
+	exec:
+	  handles:
+	    greet:
+	      cmd: [printf, '%s|']
+	      args: ["it's", '{{ arg 0 }}', '--name={{ run "name" "$HOME" }}']
+	      stdin: 'stdin for {{ arg 0 }}'
+	      flags:
+	        loud: '--loud={{ .flag }}'
+	    name:
+	      cmd: [echo]
+	    script:
+	      interpreter: [sh]
+	      script: 'printf "%s|" "$@"'
+	    stdin-run:
+	      cmd: [cat]
+	      stdin: 'hello {{ run "name" "world" }}'
+	    script-run:
+	      interpreter: [sh]
+	      script: 'printf "%s|" "{{ run "name" "in script" }}" "$@"'
+	`)
+
+func makePlanDriver(t *testing.T, out *bytes.Buffer, format string) (*Driver, *cobra.Command) {
+	testFs := fstest.MapFS{}
+	testFs[config.ConfigFileName] = &fstest.MapFile{Data: []byte(planConfig)}
+
+	s, err := New(testFs, DryRunFormat(format), Out(out))
+	require.NoError(t, err)
+	root, err := s.ConstructCommandTree(&cobra.Command{Use: "root"}, false)
+	require.NoError(t, err)
+	return s, root
+}
+
+func TestDryRunJSON(t *testing.T) {
+	out := &bytes.Buffer{}
+	s, root := makePlanDriver(t, out, "json")
+
+	s.Configure(Args("prog", "greet", "world", "--loud", "yes", "extra"))
+	s.SetupRunArgs(root)
+	_, err := executeCommand(root)
+	require.NoError(t, err)
+
+	plan := Plan{}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &plan))
+
+	pwd, _ := os.Getwd()
+	stdin := "stdin for world"
+	assert.Equal(t, Plan{
+		Handle:         "greet",
+		Argv:           []string{"printf", "%s|", "it's", "world", "--name=[name (dry-run)]", "--loud=yes", "extra"},
+		Dir:            pwd,
+		Stdin:          &stdin,
+		ConsumedArgs:   []string{"world"},
+		UnconsumedArgs: []string{"extra"},
+		ImplicitFlags:  []string{"--loud=yes"},
+		Nested: []*Plan{{
+			Handle:         "name",
+			Argv:           []string{"echo", "$HOME"},
+			Dir:            pwd,
+			ConsumedArgs:   []string{},
+			UnconsumedArgs: []string{"$HOME"},
+			ImplicitFlags:  []string{},
+		}},
+	}, plan)
+}
+
+func TestPlan(t *testing.T) {
+	out := &bytes.Buffer{}
+	s, _ := makePlanDriver(t, out, "text")
+
+	plan, err := s.Plan(Ref("greet"), Args("world", "extra"))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"printf", "%s|", "it's", "world", "--name=[name (dry-run)]", "extra"}, plan.Argv)
+	assert.Equal(t, "stdin for world", *plan.Stdin)
+	require.Len(t, plan.Nested, 1)
+	assert.Equal(t, []string{"echo", "$HOME"}, plan.Nested[0].Argv)
+	assert.Empty(t, out.String(), "nothing is written")
+
+	_, err = s.Plan(Ref("does-not-exist"))
+	assert.Error(t, err)
+}
+
+func TestDryRunSh(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is needed to run the generated script")
+	}
+	t.Setenv("HOME", "/home/me")
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			name:     "quoting-and-nested-run",
+			args:     []string{"greet", "a 'quoted' world", "extra"},
+			expected: "it's|a 'quoted' world|--name=$HOME|extra|",
+		},
+		{
+			name:     "script",
+			args:     []string{"script", "a b", "c"},
+			expected: "a b|c|",
+		},
+		{
+			name:     "nested-run-in-stdin",
+			args:     []string{"stdin-run"},
+			expected: "hello world",
+		},
+		{
+			name:     "nested-run-in-script",
+			args:     []string{"script-run", "a"},
+			expected: "in script|a|",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			s, _ := makePlanDriver(t, out, "sh")
+
+			err := s.Run(Ref(tt.args[0]), Args(tt.args[1:]...))
+			require.NoError(t, err)
+
+			sh := exec.Command("sh", "-c", out.String())
+			got, err := sh.Output()
+			require.NoError(t, err, out.String())
+			assert.Equal(t, tt.expected, string(got), out.String())
+		})
+	}
+}
+
+func TestDryRunShTempFiles(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is needed to run the generated script")
+	}
+	testFs := fstest.MapFS{
+		"assets/" + config.ConfigFileName: &fstest.MapFile{Data: []byte(dedent.Dedent(`
+			exec:
+			  handles:
+			    hello:
+			      cmd: [sh, '{{ summon "hello.sh" }}', '--conf={{ summon "conf/name.txt" }}']
+			      before: [prepare]
+			    prepare:
+			      interpreter: [sh]
+			      script: 'echo prepare'
+			      before: [setup]
+			    setup:
+			      interpreter: [sh]
+			      script: 'cat {{ summon "conf/name.txt" }}'
+			`))},
+		"assets/hello.sh":      &fstest.MapFile{Data: []byte("#!/bin/sh\necho \"hello $(cat ${1#--conf=})\"\n")},
+		"assets/conf/name.txt": &fstest.MapFile{Data: []byte("world\n")},
+	}
+	out := &bytes.Buffer{}
+	s, err := New(testFs, DryRunFormat("sh"), Out(out))
+	require.NoError(t, err)
+	require.NoError(t, s.Run(Ref("hello")))
+
+	// the summoned files are removed after the dry-run, the script writes them
+	script := out.String()
+	assert.Equal(t, 1, strings.Count(script, "trap "), script)
+	assert.NotContains(t, script, os.TempDir(), script)
+
+	dir := t.TempDir()
+	sh := exec.Command("sh", "-c", script)
+	sh.Env = append(os.Environ(), "TMPDIR="+dir)
+	got, err := sh.Output()
+	require.NoError(t, err, script)
+	assert.Equal(t, "world\nprepare\nhello world\n", string(got), script)
+
+	left, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, left, "temporary files are removed")
+}
+
+func TestShellQuote(t *testing.T) {
+	assert.Equal(t, "simple-arg", shellQuote("simple-arg"))
+	assert.Equal(t, "''", shellQuote(""))
+	assert.Equal(t, `'it'\''s'`, shellQuote("it's"))
+	assert.Equal(t, `'$HOME'`, shellQuote("$HOME"))
+}
@@ -0,0 +1,33 @@
+package summon
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/davidovich/summon/pkg/config"
+)
+
+// renderRule returns how the asset is rendered, following the render rules of
+// the config. The rule of the longest matching glob is returned.
+func (d *Driver) renderRule(assetPath string) (config.RenderRule, error) {
+	assetPath = filepath.ToSlash(assetPath)
+	var pattern string
+	for glob := range d.config.Render.Rules {
+		if matchGlob(glob, assetPath) && (len(glob) > len(pattern) || len(glob) == len(pattern) && glob < pattern) {
+			pattern = glob
+		}
+	}
+
+	rule := d.config.Render.Rules[pattern]
+	if pattern != "" && len(rule.Delims) != 0 && (len(rule.Delims) != 2 || rule.Delims[0] == "" || rule.Delims[1] == "") {
+		return rule, fmt.Errorf("invalid delims %q for %q in config %s: expected [left, right]", rule.Delims, pattern, config.ConfigFileName)
+	}
+	return rule, nil
+}
+
+// rendered reports whether the asset has the render suffix, when it is
+// configured.
+func (d *Driver) rendered(assetPath string) bool {
+	return strings.HasSuffix(assetPath, d.config.Render.Suffix)
+}
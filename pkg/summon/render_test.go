@@ -0,0 +1,96 @@
+package summon
+
+import (
+	"bytes"
+	"testing"
+	"testing/fstest"
+
+	"github.com/lithammer/dedent"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/davidovich/summon/internal/testutil"
+	"github.com/davidovich/summon/pkg/config"
+)
+
+func TestRenderRules(t *testing.T) {
+	testFs := fstest.MapFS{
+		"assets/" + config.ConfigFileName: &fstest.MapFile{Data: []byte(dedent.Dedent(`
+			render:
+			  rules:
+			    'charts/**': {raw: true}
+			    'charts/values.yaml': {delims: ['[[', ']]']}
+			    '*.gotmpl': {delims: ['<%', '%>']}
+			`))},
+		"assets/charts/templates/deploy.yaml": &fstest.MapFile{Data: []byte(`name: {{ .Release.Name }}`)},
+		"assets/charts/values.yaml":           &fstest.MapFile{Data: []byte(`image: [[ .image ]] # {{ .Values.x }}`)},
+		"assets/gen/main.gotmpl":              &fstest.MapFile{Data: []byte(`<% include "charts/templates/deploy.yaml" %> <% .image %>`)},
+		"assets/plain.txt":                    &fstest.MapFile{Data: []byte(`{{ .image }}`)},
+	}
+	image := `{"image": "app:1.0"}`
+	s, err := New(testFs, JSON(&image))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		filename string
+		expected string
+	}{
+		{name: "raw", filename: "charts/templates/deploy.yaml", expected: "name: {{ .Release.Name }}"},
+		{name: "delims", filename: "charts/values.yaml", expected: "image: app:1.0 # {{ .Values.x }}"},
+		{name: "delims-base-name-include-raw", filename: "gen/main.gotmpl", expected: "name: {{ .Release.Name }} app:1.0"},
+		{name: "default", filename: "plain.txt", expected: "app:1.0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			_, err := s.Summon(Filename(tt.filename), Dest("-"), Out(out))
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, out.String())
+		})
+	}
+
+	t.Run("invalid-delims", func(t *testing.T) {
+		testFs := fstest.MapFS{
+			"assets/" + config.ConfigFileName: &fstest.MapFile{Data: []byte("render: {rules: {'*.txt': {delims: ['[[']}}}\n")},
+			"assets/a.txt":                    &fstest.MapFile{Data: []byte("a")},
+		}
+		s, err := New(testFs)
+		require.NoError(t, err)
+		_, err = s.Summon(Filename("a.txt"), Dest("-"), Out(&bytes.Buffer{}))
+		assert.ErrorContains(t, err, `invalid delims ["[["] for "*.txt"`)
+	})
+}
+
+func TestRenderSuffix(t *testing.T) {
+	defer testutil.ReplaceFs()()
+
+	testFs := fstest.MapFS{
+		"assets/" + config.ConfigFileName:  &fstest.MapFile{Data: []byte("render: {suffix: .tmpl}\n")},
+		"assets/app/config.yaml.tmpl":      &fstest.MapFile{Data: []byte(`name: {{ .name }}`)},
+		"assets/app/{{ .name }}.txt.tmpl":  &fstest.MapFile{Data: []byte(`rendered`)},
+		"assets/app/templates/deploy.yaml": &fstest.MapFile{Data: []byte(`name: {{ .Release.Name }}`)},
+	}
+	name := `{"name": "svc"}`
+	s, err := New(testFs, JSON(&name))
+	require.NoError(t, err)
+
+	summoned, err := s.Summon(Filename("app/config.yaml.tmpl"), Dest("out"))
+	require.NoError(t, err)
+	assert.Equal(t, "out/app/config.yaml", summoned)
+
+	_, err = s.Summon(Filename("app"), Dest("all"))
+	require.NoError(t, err)
+
+	for file, expected := range map[string]string{
+		"out/app/config.yaml":       "name: svc",
+		"all/config.yaml":           "name: svc",
+		"all/svc.txt":               "rendered",
+		"all/templates/deploy.yaml": "name: {{ .Release.Name }}",
+	} {
+		content, err := afero.ReadFile(appFs, file)
+		require.NoError(t, err, file)
+		assert.Equal(t, expected, string(content), file)
+	}
+}
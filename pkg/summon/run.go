@@ -1,18 +1,23 @@
 package summon
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
+	"time"
 
 	// "github.com/google/shlex"
 	"github.com/anmitsu/go-shlex"
 	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	"golang.org/x/exp/slices"
 
+	"github.com/davidovich/summon/pkg/command"
 	"github.com/davidovich/summon/pkg/config"
 )
 
@@ -24,6 +29,16 @@ type commandSpec struct {
 	prompts string
 	// args is the command and args that get appended to the ExecEnvironment
 	args config.ArgSliceSpec
+	// stdin is rendered and fed to the command standard input
+	stdin string
+	// script is rendered to a temporary file and run by the interpreter
+	script string
+	// interpreter runs the script
+	interpreter config.ArgSliceSpec
+	// container in which the command runs
+	container *config.ContainerSpec
+	// goTool is a pinned go tool used as command
+	goTool *config.GoToolSpec
 	// subCmd sub-command of current command
 	subCmd map[string]*commandSpec
 	// flags of this command
@@ -36,78 +51,318 @@ type commandSpec struct {
 	hidden bool
 	// join is used to know if the arguments form one line of text
 	join *bool
+	// exec replaces the summon process with the command
+	exec *bool
+	// watch re-runs the command when files change
+	watch *config.WatchSpec
+	// confirm is asked to the user before execution
+	confirm string
+	// confirmTyping must be typed by the user before execution
+	confirmTyping string
+	// envFile are the dotenv files loaded in the command environment
+	envFile []string
+	// hooks run around the command
+	hooks config.HooksSpec
 }
 
 // handles are the normalized version of the configs HandleDesc
 type handles map[string]*commandSpec
 
+// renderedCmd is the result of rendering a commandSpec
+type renderedCmd struct {
+	// args is the command and its arguments
+	args []string
+	// stdin is the rendered stdin content, nil if the summon stdin is used
+	stdin *string
+	// script is the rendered script content, nil if there is no script
+	script *string
+	// scriptPath is the temporary file holding the script
+	scriptPath string
+	// consumedArgs are the user args consumed by templates
+	consumedArgs []string
+	// unusedArgs are the user args appended to the command
+	unusedArgs []string
+	// implicitFlags are the rendered flags appended to the command
+	implicitFlags []string
+	// env are the variables added to the command environment
+	env []string
+	// cleanup removes temporary files created for the command
+	cleanup func()
+}
+
+// needsChild returns true if the command cannot replace the summon process
+// because it needs summon to feed it or to clean up after it.
+func (r *renderedCmd) needsChild() bool {
+	return r.stdin != nil || r.cleanup != nil
+}
+
+// Result is the outcome of the command of a handle run by RunContext.
+type Result struct {
+	// ExitCode is the exit code of the command, -1 if it was killed
+	ExitCode int
+	// Duration is the time the command took to run
+	Duration time.Duration
+	// Argv is the rendered command line
+	Argv []string
+	// Stdout is the output of the command when it is captured
+	Stdout string
+	// Stderr is the error output of the command when it is captured
+	Stderr string
+}
+
 // Run will run executable scripts described in the summon.config.yaml file
-// of the data repository module.
+// of the data repository module. Each Run has its own invocation state, it is
+// safe to call Run concurrently.
 func (d *Driver) Run(opts ...Option) error {
+	c := d.invoke()
+	return c.maskError(c.run(opts...))
+}
+
+// RunContext runs a handle like Run and returns the Result of its command.
+// The command, and the commands of run template function calls and hooks, are
+// killed when ctx is done. The Result is returned with the error of a command
+// exiting with a non-zero code. The summon process is never replaced by the
+// command, even for handles declaring exec. A watched handle is watched until
+// ctx is done, and ctx.Err() is then returned.
+func (d *Driver) RunContext(ctx context.Context, opts ...Option) (*Result, error) {
+	c := d.invoke()
+	c.inv.ctx = ctx
+	c.inv.noExec = true
+	err := c.run(opts...)
+	return &c.inv.result, c.maskError(err)
+}
+
+// run runs the handle in the invocation of d.
+func (d *Driver) run(opts ...Option) error {
 	err := d.Configure(opts...)
 	if err != nil {
 		return err
 	}
+	defer d.removeEphemeral()
 
-	cmdArgs, err := d.buildCmdArgs()
+	cmdSpec, ref := d.getCmdSpec()
+	if cmdSpec != nil && !d.inv.nested && !d.opts.dryrun {
+		spec, debounce, err := d.watchSpec(cmdSpec)
+		if err != nil {
+			return err
+		}
+		if spec != nil {
+			return d.watch(ref, spec, debounce)
+		}
+	}
+	if d.opts.explain {
+		d.inv.explanation = &explanation{handle: ref}
+	}
+	rendered, err := d.buildCmdArgs()
 	if err != nil {
-		return err
+		return d.handleError(ref, err)
+	}
+	if rendered.cleanup != nil {
+		defer rendered.cleanup()
+	}
+	d.inv.result.Argv = rendered.args
+	if d.inv.explanation != nil {
+		return d.inv.explanation.write(d.opts.out, rendered.args, d.mask)
 	}
 
-	cmd := d.execCommand(cmdArgs[0], cmdArgs[1:]...)
-	if d.opts.debug || d.opts.dryrun {
-		_, ref := d.getCmdSpec()
+	cmd := d.newCmd(rendered)
+	hooks := d.hooksOf(cmdSpec)
+	structured := d.opts.dryRunFormat == dryRunJSON || d.opts.dryRunFormat == dryRunSh
+	if d.opts.dryrun {
+		d.inv.lastPlan = d.newPlan(ref, rendered, cmd)
+		d.inv.lastPlan.Before, err = d.runHooks(hookBefore, ref, hooks.before)
+		if err != nil {
+			return err
+		}
+	}
+	if !d.opts.dryrun && d.opts.debug || d.opts.dryrun && !structured {
 		msg := "Executing"
 		if d.opts.dryrun {
 			msg = "Would execute"
 		}
-		fmt.Fprintf(os.Stderr, "%s [%s] -> `%s`...\n", msg, ref, cmd)
+		fmt.Fprintf(d.stderr(), "%s [%s] -> `%s`...\n", msg, ref, d.mask(cmd.String()))
+		if len(rendered.env) != 0 {
+			fmt.Fprintf(d.stderr(), "With env [%s] -> %s\n", ref, maskedEnv(rendered.env))
+		}
+		if d.opts.dryrun && rendered.script != nil {
+			fmt.Fprintf(d.stderr(), "With script [%s] ->\n%s\n", ref, d.mask(*rendered.script))
+		}
+		if d.opts.dryrun && rendered.stdin != nil {
+			fmt.Fprintf(d.stderr(), "With stdin [%s] ->\n%s\n", ref, d.mask(*rendered.stdin))
+		}
+	}
+
+	if d.opts.dryrun {
+		d.setRunResult(nil, 0)
+		d.inv.lastPlan.After, err = d.runHooks(hookAfter, ref, hooks.after)
+		if err != nil {
+			return err
+		}
+		d.inv.lastPlan.OnError, err = d.runHooks(hookOnError, ref, hooks.onError)
+		if err != nil {
+			return err
+		}
+		if structured && !d.inv.nested {
+			return d.inv.lastPlan.write(d.opts.out, d.opts.dryRunFormat)
+		}
+		return nil
+	}
+
+	err = d.confirm(ref, cmd)
+	if err != nil {
+		return err
 	}
 
-	if !d.opts.dryrun {
-		cmd.Stdin = os.Stdin
-		cmd.Stdout = d.opts.out
-		cmd.Stderr = os.Stderr
+	cmd.Stdin = d.stdin()
+	if rendered.stdin != nil {
+		cmd.Stdin = strings.NewReader(*rendered.stdin)
+	}
+	cmd.Stdout = d.opts.out
+	cmd.Stderr = d.stderr()
+	if d.opts.capture {
+		stdout, stderr := &strings.Builder{}, &strings.Builder{}
+		cmd.Stdout, cmd.Stderr = stdout, stderr
+		defer func() {
+			d.inv.result.Stdout = stdout.String()
+			d.inv.result.Stderr = stderr.String()
+		}()
+	}
 
-		return cmd.Run()
+	if hooks.empty() && !rendered.needsChild() && d.canExec() && cmd.Exec != nil {
+		return d.execute(cmd, func(cmd *command.Cmd) error { return cmd.Exec() })
 	}
+	return d.runWithHooks(d.inv.ctx, ref, hooks, func() error { return d.runCmd(cmd) })
+}
 
-	return nil
+// canExec returns true if the process can be replaced by the command. This is
+// only possible if exec mode was requested, the handle is not run by
+// RunContext, the stdio of the command is not injected with In, Out or Err or
+// captured (like in a run template function call) and there are no ephemeral
+// summoned files to clean up.
+func (d *Driver) canExec() bool {
+	wanted := d.opts.exec
+	if cmdSpec, _ := d.getCmdSpec(); cmdSpec != nil && cmdSpec.exec != nil && *cmdSpec.exec {
+		wanted = true
+	}
+	stdio := d.opts.in == nil && d.opts.out == os.Stdout && d.opts.errOut == nil && !d.opts.capture
+	return wanted && !d.inv.noExec && stdio && d.inv.ephemeralDir == ""
 }
 
-func (d *Driver) buildCmdArgs() ([]string, error) {
+func (d *Driver) buildCmdArgs() (_ *renderedCmd, err error) {
 	// find the corresponding command
 	cmdSpec, ref := d.getCmdSpec()
 	if cmdSpec == nil {
 		return nil, fmt.Errorf("could not find exec handle reference '%s' in config %s", ref, config.ConfigFileName)
 	}
 
-	_, err := d.renderTemplate(cmdSpec.prompts)
+	_, err = d.renderTemplate(cmdSpec.prompts)
 	if err != nil {
 		return nil, fmt.Errorf("could not get all prompts for exec handle '%s' in config %s, error: %s", ref, config.ConfigFileName, err)
 	}
+	if cmdSpec.prompts != "" {
+		d.inv.explanation.add(explainStep{section: "prompts", raw: cmdSpec.prompts, note: "(rendered for side effects)"})
+	}
+
+	envVars, err := d.loadEnvFiles(d.envFiles(cmdSpec))
+	if err != nil {
+		return nil, fmt.Errorf("could not load env files for exec handle '%s' in config %s, error: %s", ref, config.ConfigFileName, err)
+	}
+	d.opts.data["envfile"] = envFileData(envVars)
+	if d.inv.secrets != nil {
+		for _, v := range envVars {
+			if d.inv.secrets.isSecretEnv(v.name) {
+				d.inv.secrets.add(v.value)
+			}
+		}
+	}
+
+	var stdin *string
+	if cmdSpec.stdin != "" {
+		renderedStdin, err := d.renderTemplate(cmdSpec.stdin)
+		if err != nil {
+			return nil, fmt.Errorf("could not render stdin for exec handle '%s' in config %s, error: %s", ref, config.ConfigFileName, err)
+		}
+		stdin = &renderedStdin
+		d.inv.explanation.add(explainStep{section: "stdin", raw: cmdSpec.stdin, rendered: []string{renderedStdin}})
+	}
+
+	var script *string
+	var scriptFile string
+	var cleanup func()
+	command := cmdSpec.command
+	if cmdSpec.script != "" {
+		var renderedScript, scriptPath string
+		renderedScript, err = d.renderTemplate(cmdSpec.script)
+		if err != nil {
+			return nil, fmt.Errorf("could not render script for exec handle '%s' in config %s, error: %s", ref, config.ConfigFileName, err)
+		}
+		scriptPath, err = writeScript(renderedScript)
+		if err != nil {
+			return nil, err
+		}
+		script = &renderedScript
+		scriptFile = scriptPath
+		cleanup = func() { appFs.Remove(scriptPath) }
+		defer func() {
+			// do not leave the script behind if rendering fails
+			if err != nil {
+				cleanup()
+			}
+		}()
+
+		command = config.ArgSliceSpec{cmdSpec.interpreter, scriptPath}
+		if cmdSpec.interpreter == nil {
+			command = config.ArgSliceSpec{"sh", scriptPath}
+		}
+		d.inv.explanation.add(explainStep{section: "script", raw: cmdSpec.script, rendered: []string{renderedScript},
+			note: fmt.Sprintf("written to %s", scriptPath)})
+	}
+
+	if cmdSpec.goTool != nil {
+		var bin string
+		bin, err = d.ensureGoTool(cmdSpec.goTool)
+		if err != nil {
+			return nil, err
+		}
+		command = config.ArgSliceSpec{bin}
+		d.inv.explanation.add(explainStep{section: "go tool", note: fmt.Sprintf("%s@%s installed as %s", cmdSpec.goTool.Module, cmdSpec.goTool.Version, bin)})
+	}
 
-	execEnv, err := d.RenderArgs(FlattenStrings(cmdSpec.command)...)
+	execEnv, err := d.renderArgs("cmd", FlattenStrings(command)...)
 	if err != nil {
 		return nil, err
 	}
 
+	if cmdSpec.container != nil {
+		containerArgs, err := d.containerArgs(cmdSpec.container)
+		if err != nil {
+			return nil, fmt.Errorf("could not render container for exec handle '%s' in config %s, error: %s", ref, config.ConfigFileName, err)
+		}
+		execEnv = append(containerArgs, execEnv...)
+		d.inv.explanation.add(explainStep{section: "container", rendered: containerArgs, note: "(prepended)"})
+	}
+
 	args := FlattenStrings(cmdSpec.args)
 	// Render and flatten arguments array of arrays to simple array
 	if cmdSpec.join != nil && *cmdSpec.join {
 		oneLine := strings.Join(args, " ")
 		args = []string{oneLine}
+		d.inv.explanation.add(explainStep{section: "args", note: "(joined in one arg)"})
 	}
-	arguments, err := d.RenderArgs(args...)
+	arguments, err := d.renderArgs("args", args...)
 	if err != nil {
 		return nil, err
 	}
 
 	// Render flags
 	renderedFlags := []string{}
-	for _, flag := range d.flagsToRender {
+	for _, flag := range d.inv.flags {
 		// if the flag was used in a template call do not use it implicitely
 		if flag.explicit {
+			if d.inv.explanation != nil && flag.rendered != "" {
+				d.inv.explanation.add(explainStep{section: "flags", raw: flag.effect, rendered: []string{flag.rendered},
+					note: fmt.Sprintf("--%s=%s placed by flagValue", flag.name, flag.userValue)})
+			}
 			continue
 		}
 		renderedFlag, err := flag.renderTemplate()
@@ -115,6 +370,8 @@ func (d *Driver) buildCmdArgs() ([]string, error) {
 			return nil, err
 		}
 		renderedFlags = append(renderedFlags, renderedFlag)
+		d.inv.explanation.add(explainStep{section: "flags", raw: flag.effect, rendered: []string{renderedFlag},
+			note: fmt.Sprintf("--%s=%s implicit, appended", flag.name, flag.userValue)})
 	}
 
 	var finalArgs []string
@@ -122,8 +379,11 @@ func (d *Driver) buildCmdArgs() ([]string, error) {
 
 	finalArgs = append(finalArgs, renderedFlags...)
 	// add user args that were not consumed by a template render
-	unusedArgs := computeUnused(d.opts.args, d.opts.argsConsumed)
+	unusedArgs := computeUnused(d.opts.args, d.inv.argsConsumed)
 	finalArgs = append(finalArgs, unusedArgs...)
+	if len(unusedArgs) != 0 {
+		d.inv.explanation.add(explainStep{section: "unused args", rendered: unusedArgs, note: "(not consumed by a template, appended)"})
+	}
 
 	// intersperse help if it was wanted
 	if d.opts.helpWanted.helpFlag != "" {
@@ -141,11 +401,40 @@ func (d *Driver) buildCmdArgs() ([]string, error) {
 		} else {
 			finalArgs = append(finalArgs, d.opts.helpWanted.helpFlag)
 		}
+		d.inv.explanation.add(explainStep{section: "help", rendered: []string{d.opts.helpWanted.helpFlag},
+			note: fmt.Sprintf("reinserted at position %d", len(execEnv)+helpPos)})
 	}
 
 	finalCmd := append(execEnv, finalArgs...)
 
-	return finalCmd, nil
+	return &renderedCmd{
+		args:          finalCmd,
+		stdin:         stdin,
+		script:        script,
+		scriptPath:    scriptFile,
+		cleanup:       cleanup,
+		consumedArgs:  consumedArgs(d.opts.args, d.inv.argsConsumed),
+		unusedArgs:    unusedArgs,
+		implicitFlags: renderedFlags,
+		env:           envList(envVars),
+	}, nil
+}
+
+// writeScript writes a rendered script to a private temporary file and
+// returns its path.
+func writeScript(script string) (string, error) {
+	f, err := afero.TempFile(appFs, "", filepath.Base(Name)+"-script-*")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	_, err = f.WriteString(script)
+	if err != nil {
+		appFs.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
 }
 
 func (d *Driver) getCmdSpec() (*commandSpec, string) {
@@ -159,6 +448,11 @@ func (d *Driver) getCmdSpec() (*commandSpec, string) {
 				break
 			}
 		}
+		// without a command tree, like when summon is used as a library
+		if cmdSpec == nil && d.handles[d.opts.ref] != nil {
+			cmdSpec = d.handles[d.opts.ref]
+			ref = d.opts.ref
+		}
 	} else {
 		cmdSpec = d.cmdToSpec[d.opts.cobraCmd]
 		ref = d.opts.cobraCmd.Name()
@@ -167,37 +461,63 @@ func (d *Driver) getCmdSpec() (*commandSpec, string) {
 }
 
 func (d *Driver) RenderArgs(args ...string) ([]string, error) {
+	return d.renderArgs("", args...)
+}
+
+// renderArgs renders args, recording each one in section of the explanation
+// if one was requested.
+func (d *Driver) renderArgs(section string, args ...string) ([]string, error) {
 	targets := make([]string, 0, len(args))
 	for _, t := range args {
-		rt, err := d.renderTemplate(t)
+		var before map[int]struct{}
+		if d.inv.explanation != nil {
+			before = snapshot(d.inv.argsConsumed)
+		}
+		renderedTargets, err := d.renderArg(t)
 		if err != nil {
 			return nil, err
 		}
-		if rt == "" {
-			continue
+		if section != "" && d.inv.explanation != nil {
+			d.inv.explanation.add(explainStep{
+				section:  section,
+				raw:      t,
+				rendered: renderedTargets,
+				consumed: consumedSince(d.opts.args, before, d.inv.argsConsumed),
+			})
 		}
+		targets = append(targets, renderedTargets...)
+	}
+	return targets, nil
+}
+
+// renderArg renders one arg, which can produce zero or many args.
+func (d *Driver) renderArg(t string) ([]string, error) {
+	rt, err := d.renderTemplate(t)
+	if err != nil {
+		return nil, err
+	}
+	if rt == "" {
+		return []string{}, nil
+	}
 
-		var renderedTargets = []string{rt}
-		if strings.HasPrefix(rt, "[") && strings.HasSuffix(rt, "]") {
-			inner := strings.Trim(rt, "[]")
+	var renderedTargets = []string{rt}
+	if strings.HasPrefix(rt, "[") && strings.HasSuffix(rt, "]") {
+		inner := strings.Trim(rt, "[]")
 
-			if inner == "" {
-				continue
-			} else {
-				if inner == `""` {
-					renderedTargets = []string{""}
-				} else {
-					renderedTargets, err = shlex.Split(inner, true)
-					if err != nil {
-						return nil, err
-					}
-				}
+		if inner == "" {
+			return []string{}, nil
+		}
+		if inner == `""` {
+			renderedTargets = []string{""}
+		} else {
+			renderedTargets, err = shlex.Split(inner, true)
+			if err != nil {
+				return nil, err
 			}
 		}
-
-		targets = append(targets, renderedTargets...)
 	}
-	return targets, nil
+
+	return renderedTargets, nil
 }
 
 func computeUnused(args []string, consumed map[int]struct{}) []string {
@@ -222,6 +542,23 @@ func normalizeExecDesc(argsDesc interface{}) (*commandSpec, error) {
 	case config.CmdDesc:
 		c.command = descType.Cmd
 		c.args = descType.Args
+		c.stdin = descType.Stdin
+		c.script = descType.Script
+		c.interpreter = descType.Interpreter
+		c.container = descType.Container
+		c.goTool = descType.Go
+		if c.goTool != nil && (c.command != nil || c.script != "") {
+			return nil, fmt.Errorf("in config %s: go cannot be used with cmd or script",
+				config.ConfigFileName)
+		}
+		if c.script != "" && c.command != nil {
+			return nil, fmt.Errorf("in config %s: cmd and script cannot be used together",
+				config.ConfigFileName)
+		}
+		if c.script != "" && c.container != nil {
+			return nil, fmt.Errorf("in config %s: container and script cannot be used together",
+				config.ConfigFileName)
+		}
 		c.prompts = descType.Prompts
 		c.help = descType.Help
 		c.completion = descType.Completion
@@ -229,6 +566,14 @@ func normalizeExecDesc(argsDesc interface{}) (*commandSpec, error) {
 		if descType.Join != nil {
 			c.join = descType.Join
 		}
+		if descType.Exec != nil {
+			c.exec = descType.Exec
+		}
+		c.watch = descType.Watch
+		c.confirm = descType.Confirm
+		c.confirmTyping = descType.ConfirmTyping
+		c.envFile = descType.EnvFile
+		c.hooks = descType.HooksSpec
 		if descType.SubCmd != nil {
 			c.subCmd = make(map[string]*commandSpec)
 			for subCmdName, execDesc := range descType.SubCmd {
@@ -237,13 +582,34 @@ func normalizeExecDesc(argsDesc interface{}) (*commandSpec, error) {
 					return nil, err
 				}
 				// inherit command if not set explicitly
-				if subCmd.command == nil {
+				if subCmd.command == nil && subCmd.script == "" && subCmd.goTool == nil {
 					subCmd.command = c.command
+					subCmd.goTool = c.goTool
+				}
+				// inherit interpreter if not set explicitly
+				if subCmd.interpreter == nil {
+					subCmd.interpreter = c.interpreter
+				}
+				// inherit container if not set explicitly
+				if subCmd.container == nil && subCmd.script == "" {
+					subCmd.container = c.container
 				}
 				// propagate join to declared sub-commands
 				if subCmd.join == nil {
 					subCmd.join = c.join
 				}
+				// propagate exec mode to declared sub-commands
+				if subCmd.exec == nil {
+					subCmd.exec = c.exec
+				}
+				// inherit env files if not set explicitly
+				if subCmd.envFile == nil {
+					subCmd.envFile = c.envFile
+				}
+				// inherit hooks if none are set explicitly
+				if subCmd.hooks.Before == nil && subCmd.hooks.After == nil && subCmd.hooks.OnError == nil {
+					subCmd.hooks = c.hooks
+				}
 				c.subCmd[subCmdName] = subCmd
 			}
 		}
@@ -381,10 +747,12 @@ func (d *Driver) addCmdSpec(root *cobra.Command, arg string, cmdSpec *commandSpe
 	}
 	if cmdSpec.completion != "" {
 		subCmd.ValidArgsFunction = func(cmd *cobra.Command, cobraArgs []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-			d.Configure(Args(extractUnknownArgs(cmd, d.opts.initialArgs, d.opts.args)...))
-			inlineComp, err := d.RenderArgs(cmdSpec.completion)
+			c := d.invoke()
+			c.Configure(Args(extractUnknownArgs(cmd, d.opts.initialArgs, d.opts.args)...))
+			defer c.removeEphemeral()
+			inlineComp, err := c.RenderArgs(cmdSpec.completion)
 			if err != nil {
-				fmt.Fprintln(cmd.ErrOrStderr(), err)
+				fmt.Fprintln(cmd.ErrOrStderr(), c.maskError(err))
 				return nil, cobra.ShellCompDirectiveError
 			}
 
@@ -471,7 +839,7 @@ func (d *Driver) SetupRunArgs(root *cobra.Command) {
 		if helpPos+1 < len(allArgs) {
 			d.opts.helpWanted.nextToHelp = allArgs[helpPos+1]
 		}
-		fl = d.AddFlag(root, "help", &config.FlagSpec{Effect: "--help", Explicit: true}, global, func() {
+		fl = d.AddFlag(root, "help", &config.FlagSpec{Effect: "--help", Explicit: true}, global, func(d *Driver) {
 			// we were called in by rendering, disable implicit add effect
 			d.opts.helpWanted.helpFlag = ""
 		})
@@ -2,15 +2,21 @@ package summon
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 	"testing/fstest"
+	"time"
 
 	"github.com/lithammer/dedent"
+	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	"github.com/stretchr/testify/assert"
@@ -50,10 +56,16 @@ func TestRun(t *testing.T) {
 		{
 			name:      "self-reference-invoker", // bash
 			cmd:       []string{"bash-self-ref"},
-			expect:    [][]string{{"bash", filepath.Join(os.TempDir(), "hello.sh")}},
+			contains:  [][]string{{filepath.Join(os.TempDir(), "summon-")}},
 			wantErr:   false,
 			replaceFS: true,
 		},
+		{
+			name:      "summoned-asset-as-command",
+			cmd:       []string{"run-asset"},
+			contains:  [][]string{{"hello.sh"}},
+			replaceFS: true,
+		},
 		{
 			name:      "summon-function-with-destination",
 			cmd:       []string{"summon-with-destination"},
@@ -239,6 +251,370 @@ func TestRun(t *testing.T) {
 	}
 }
 
+func TestExecMode(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		opts     []Option
+		wantExec [][]string
+		wantRun  [][]string
+	}{
+		{
+			name:     "exec-handle",
+			args:     []string{"exec-mode"},
+			wantExec: [][]string{{"bash", "hello.sh"}},
+		},
+		{
+			name:     "exec-option",
+			args:     []string{"hello-bash"},
+			opts:     []Option{Exec(true)},
+			wantExec: [][]string{{"bash", "hello.sh"}},
+		},
+		{
+			name:    "captured-run-falls-back-to-child",
+			args:    []string{"run-exec-mode"},
+			opts:    []Option{Exec(true)},
+			wantRun: [][]string{{"bash", "hello.sh"}},
+			// the main command is exec'ed
+			wantExec: [][]string{{"bash", "ran"}},
+		},
+		{
+			name:    "no-exec",
+			args:    []string{"hello-bash"},
+			wantRun: [][]string{{"bash", "hello.sh"}},
+		},
+		{
+			name:    "injected-stdin-falls-back-to-child",
+			args:    []string{"exec-mode"},
+			opts:    []Option{In(strings.NewReader("in"))},
+			wantRun: [][]string{{"bash", "hello.sh"}},
+		},
+		{
+			name:    "injected-stderr-falls-back-to-child",
+			args:    []string{"exec-mode"},
+			opts:    []Option{Err(&bytes.Buffer{})},
+			wantRun: [][]string{{"bash", "hello.sh"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var execCalls, runCalls [][]string
+			s, err := New(summonTestFS, ExecCmd(func(c string, args ...string) *command.Cmd {
+				call := append([]string{c}, args...)
+				cmd := &command.Cmd{Cmd: &exec.Cmd{}}
+				cmd.Run = func() error {
+					runCalls = append(runCalls, call)
+					fmt.Fprint(cmd.Stdout, "ran")
+					return nil
+				}
+				cmd.Exec = func() error {
+					execCalls = append(execCalls, call)
+					return nil
+				}
+				return cmd
+			}))
+			require.NoError(t, err)
+			_, err = s.ConstructCommandTree(&cobra.Command{Use: "root"}, false)
+			require.NoError(t, err)
+
+			err = s.Run(append(tt.opts, Ref(tt.args[0]), Args(tt.args[1:]...))...)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantExec, execCalls)
+			assert.Equal(t, tt.wantRun, runCalls)
+		})
+	}
+}
+
+func TestStdin(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		wantArgs  []string
+		wantStdin string
+	}{
+		{
+			name:      "rendered-stdin-consumes-args",
+			args:      []string{"stdin-render", "world", "remainder"},
+			wantArgs:  []string{"cat", "remainder"},
+			wantStdin: "hello world",
+		},
+		{
+			name:      "summoned-asset-as-stdin",
+			args:      []string{"stdin-summon"},
+			wantArgs:  []string{"bash"},
+			wantStdin: "#!/bin/bash\n\necho hello args: $@\necho this is the current environment:\nenv\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotArgs []string
+			var gotStdin []byte
+			s, err := New(summonTestFS, Exec(true), ExecCmd(func(c string, args ...string) *command.Cmd {
+				cmd := &command.Cmd{Cmd: &exec.Cmd{}}
+				cmd.Run = func() (err error) {
+					gotArgs = append([]string{c}, args...)
+					gotStdin, err = io.ReadAll(cmd.Stdin)
+					return err
+				}
+				cmd.Exec = func() error {
+					return fmt.Errorf("a rendered stdin cannot be exec'ed")
+				}
+				return cmd
+			}))
+			require.NoError(t, err)
+			_, err = s.ConstructCommandTree(&cobra.Command{Use: "root"}, false)
+			require.NoError(t, err)
+
+			err = s.Run(Ref(tt.args[0]), Args(tt.args[1:]...))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantArgs, gotArgs)
+			assert.Equal(t, tt.wantStdin, string(gotStdin))
+		})
+	}
+}
+
+func TestScript(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		wantArgs   []string
+		scriptPos  int
+		wantScript string
+	}{
+		{
+			name:       "interpreter-with-user-args",
+			args:       []string{"script", "first", "second", "third"},
+			wantArgs:   []string{"bash", "-euo", "pipefail", "second", "third"},
+			scriptPos:  3,
+			wantScript: "echo first \"$@\"\n",
+		},
+		{
+			name:       "sub-command-inherits-interpreter",
+			args:       []string{"script", "sub"},
+			wantArgs:   []string{"bash", "-euo", "pipefail"},
+			scriptPos:  3,
+			wantScript: "echo sub",
+		},
+		{
+			name:       "default-interpreter",
+			args:       []string{"script-default-interpreter"},
+			wantArgs:   []string{"sh"},
+			scriptPos:  1,
+			wantScript: "echo hello",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer testutil.ReplaceFs()()
+
+			var scriptPath string
+			s, err := New(summonTestFS, ExecCmd(func(c string, args ...string) *command.Cmd {
+				cmd := &command.Cmd{Cmd: &exec.Cmd{}}
+				cmd.Run = func() error {
+					// the script file is inserted after the interpreter
+					all := append([]string{c}, args...)
+					scriptPath = all[tt.scriptPos]
+					got := append(all[:tt.scriptPos:tt.scriptPos], all[tt.scriptPos+1:]...)
+					assert.Equal(t, tt.wantArgs, got)
+
+					script, err := afero.ReadFile(appFs, scriptPath)
+					assert.NoError(t, err)
+					assert.Equal(t, tt.wantScript, string(script))
+					return nil
+				}
+				return cmd
+			}))
+			require.NoError(t, err)
+			root, err := s.ConstructCommandTree(&cobra.Command{Use: "root"}, false)
+			require.NoError(t, err)
+
+			cobraCmd, args, err := root.Find(tt.args)
+			require.NoError(t, err)
+
+			err = s.Run(CobraCmd(cobraCmd), Args(args...))
+			assert.NoError(t, err)
+			assert.NotEmpty(t, scriptPath)
+
+			exists, _ := afero.Exists(appFs, scriptPath)
+			assert.False(t, exists, "script should be removed after run")
+		})
+	}
+}
+
+func TestSummonFunctionLifecycle(t *testing.T) {
+	defer testutil.ReplaceFs()()
+	t.Setenv("XDG_CACHE_HOME", "/cache")
+	t.Setenv("HOME", "/home")
+
+	var summoned []string
+	s, err := New(summonTestFS, ExecCmd(func(c string, args ...string) *command.Cmd {
+		cmd := &command.Cmd{Cmd: &exec.Cmd{}}
+		cmd.Run = func() error {
+			exists, _ := afero.Exists(appFs, args[0])
+			assert.True(t, exists, "summoned file should exist while running")
+			summoned = append(summoned, args[0])
+			return nil
+		}
+		return cmd
+	}))
+	require.NoError(t, err)
+	_, err = s.ConstructCommandTree(&cobra.Command{Use: "root"}, false)
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		err = s.Run(Ref("bash-self-ref"))
+		require.NoError(t, err)
+		err = s.Run(Ref("summon-keep"))
+		require.NoError(t, err)
+	}
+	require.Len(t, summoned, 4)
+
+	t.Run("ephemeral-files-are-private-and-removed", func(t *testing.T) {
+		assert.NotEqual(t, summoned[0], summoned[2])
+		for _, ephemeral := range []string{summoned[0], summoned[2]} {
+			assert.Equal(t, "hello.sh", filepath.Base(ephemeral))
+			exists, _ := afero.Exists(appFs, filepath.Dir(ephemeral))
+			assert.False(t, exists)
+		}
+	})
+
+	t.Run("kept-files-are-content-addressed", func(t *testing.T) {
+		assert.Equal(t, summoned[1], summoned[3])
+		assert.True(t, strings.HasPrefix(summoned[1], filepath.Join("/cache", "summon", "summoned")))
+		content, err := afero.ReadFile(appFs, summoned[1])
+		assert.NoError(t, err)
+		assert.Contains(t, string(content), "echo hello args")
+	})
+}
+
+func TestConcurrentRuns(t *testing.T) {
+	s, _ := makePlanDriver(t, &bytes.Buffer{}, "json")
+
+	const runs = 20
+	outs := make([]*bytes.Buffer, runs)
+	errs := make([]error, runs)
+	var wg sync.WaitGroup
+	for i := 0; i < runs; i++ {
+		outs[i] = &bytes.Buffer{}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = s.Run(Ref("greet"), Args(fmt.Sprint("world-", i), "extra"), Out(outs[i]))
+		}(i)
+	}
+	wg.Wait()
+
+	for i := 0; i < runs; i++ {
+		require.NoError(t, errs[i])
+		plan := Plan{}
+		require.NoError(t, json.Unmarshal(outs[i].Bytes(), &plan))
+		assert.Equal(t, []string{"printf", "%s|", "it's", fmt.Sprint("world-", i), "--name=[name (dry-run)]", "extra"}, plan.Argv)
+		assert.Equal(t, []string{"extra"}, plan.UnconsumedArgs)
+		assert.Len(t, plan.Nested, 1)
+	}
+}
+
+func TestRunContext(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is needed to run the handles")
+	}
+	testFs := fstest.MapFS{}
+	testFs[config.ConfigFileName] = &fstest.MapFile{Data: []byte(dedent.Dedent(`
+		exec:
+		  handles:
+		    greet:
+		      cmd: [echo, hello]
+		      args: ['{{ arg 0 }}']
+		    cat:
+		      cmd: [cat]
+		    fail:
+		      cmd: [sh, -c, 'echo oops >&2; exit 3']
+		    sleep:
+		      cmd: [sh, -c, 'sleep 10']
+		`))}
+	// no command tree is needed to run handles from a library
+	s, err := New(testFs)
+	require.NoError(t, err)
+
+	t.Run("capture", func(t *testing.T) {
+		res, err := s.RunContext(context.Background(), Ref("greet"), Args("world"), Capture(true))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"echo", "hello", "world"}, res.Argv)
+		assert.Equal(t, "hello world\n", res.Stdout)
+		assert.Equal(t, 0, res.ExitCode)
+	})
+
+	t.Run("in", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		_, err := s.RunContext(context.Background(), Ref("cat"), In(strings.NewReader("from reader")), Out(out))
+		require.NoError(t, err)
+		assert.Equal(t, "from reader", out.String())
+	})
+
+	t.Run("exit-code", func(t *testing.T) {
+		stderr := &bytes.Buffer{}
+		res, err := s.RunContext(context.Background(), Ref("fail"), Err(stderr))
+		var exitErr *exec.ExitError
+		require.ErrorAs(t, err, &exitErr)
+		assert.Equal(t, 3, res.ExitCode)
+		assert.Equal(t, "oops\n", stderr.String())
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		res, err := s.RunContext(ctx, Ref("sleep"))
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Less(t, res.Duration, 5*time.Second)
+	})
+
+	t.Run("diagnostics", func(t *testing.T) {
+		stderr := &bytes.Buffer{}
+		_, err := s.RunContext(context.Background(), Ref("greet"), Args("world"), DryRun(true), Err(stderr))
+		require.NoError(t, err)
+		assert.Regexp(t, "^Would execute \\[greet\\] -> `.*echo hello world`...\n$", stderr.String())
+	})
+}
+
+func TestRunContextFactory(t *testing.T) {
+	testFs := fstest.MapFS{}
+	testFs[config.ConfigFileName] = &fstest.MapFile{Data: []byte(dedent.Dedent(`
+		exec:
+		  handles:
+		    replace:
+		      cmd: [echo, replaced]
+		      exec: true
+		`))}
+	var runs, execs int
+	s, err := New(testFs, ExecCmd(func(c string, args ...string) *command.Cmd {
+		cmd := &command.Cmd{Cmd: exec.Command(c, args...)}
+		cmd.Run = func() error {
+			runs++
+			return nil
+		}
+		cmd.Exec = func() error {
+			execs++
+			return nil
+		}
+		return cmd
+	}))
+	require.NoError(t, err)
+
+	// the Run of the factory is used with a cancellable context, and the
+	// process is not replaced even with the default Out
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	res, err := s.RunContext(ctx, Ref("replace"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"echo", "replaced"}, res.Argv)
+	assert.Equal(t, 1, runs)
+	assert.Equal(t, 0, execs)
+
+	// Run still replaces the process
+	require.NoError(t, s.Run(Ref("replace")))
+	assert.Equal(t, 1, execs)
+}
+
 func TestFailRunHelper(t *testing.T) {
 	testutil.TestFailRunHelper()
 }
@@ -0,0 +1,176 @@
+package summon
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/davidovich/summon/pkg/config"
+)
+
+// secretMask replaces secret values in diagnostic output.
+const secretMask = "***"
+
+// secrets holds what must not appear in diagnostic output. Each invocation has
+// its own values, shared with the invocations of run template function calls.
+type secrets struct {
+	// env are globs of environment variable names holding secrets
+	env []string
+	// data are globs of dotted template data paths holding secrets
+	data []string
+	// patterns match secret values
+	patterns []*regexp.Regexp
+	// values are secret values seen while rendering, like secret flags
+	values map[string]struct{}
+}
+
+func newSecrets(spec config.SecretsSpec) (*secrets, error) {
+	s := &secrets{
+		env:    spec.Env,
+		data:   spec.Data,
+		values: map[string]struct{}{},
+	}
+	for _, p := range spec.Patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid secrets pattern %q in config %s: %w", p, config.ConfigFileName, err)
+		}
+		s.patterns = append(s.patterns, re)
+	}
+	return s, nil
+}
+
+// fresh returns secrets with the same rules as s, but without the values
+// seen by previous invocations.
+func (s *secrets) fresh() *secrets {
+	if s == nil {
+		return nil
+	}
+	c := *s
+	c.values = map[string]struct{}{}
+	return &c
+}
+
+// add records secret values.
+func (s *secrets) add(values ...string) {
+	for _, v := range values {
+		if v != "" {
+			s.values[v] = struct{}{}
+		}
+	}
+}
+
+// isSecretEnv returns true if the environment variable name holds a secret.
+func (s *secrets) isSecretEnv(name string) bool {
+	return matchAnyGlob(s.env, name)
+}
+
+// dataValues appends the string values of data that are secret to values.
+// Keys are matched by their dotted path (like creds.token, or items.0.key in
+// lists), and all the values under a matched key are secret.
+func (s *secrets) dataValues(values []string, keys []string, data interface{}, secret bool) []string {
+	if !secret && len(keys) != 0 {
+		secret = s.isSecretData(keys)
+	}
+	switch v := data.(type) {
+	case string:
+		if secret && v != "" {
+			values = append(values, v)
+		}
+	case map[string]interface{}:
+		for key, value := range v {
+			values = s.dataValues(values, append(keys[:len(keys):len(keys)], key), value, secret)
+		}
+	case []interface{}:
+		for i, value := range v {
+			values = s.dataValues(values, append(keys[:len(keys):len(keys)], strconv.Itoa(i)), value, secret)
+		}
+	}
+	return values
+}
+
+// isSecretData returns true if the data at the keys path holds a secret.
+// Globs are dotted paths, like .creds.token or creds.*, and globs without a
+// dot match keys at any depth.
+func (s *secrets) isSecretData(keys []string) bool {
+	name := strings.Join(keys, "/")
+	for _, glob := range s.data {
+		glob = strings.ReplaceAll(strings.TrimPrefix(glob, "."), ".", "/")
+		if matchGlob(glob, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// mask replaces the secrets of text by ***.
+func (d *Driver) mask(text string) string {
+	s := d.inv.secrets
+	if s == nil {
+		return text
+	}
+	values := make([]string, 0, len(s.values))
+	for v := range s.values {
+		values = append(values, v)
+	}
+	for _, e := range os.Environ() {
+		name, value, _ := strings.Cut(e, "=")
+		if value != "" && s.isSecretEnv(name) {
+			values = append(values, value)
+		}
+	}
+	values = s.dataValues(values, nil, d.opts.data, false)
+	// replace longer values first, so that a secret containing another one is
+	// fully masked
+	sort.Slice(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+	for _, v := range values {
+		text = strings.ReplaceAll(text, v, secretMask)
+	}
+	for _, re := range s.patterns {
+		text = re.ReplaceAllString(text, secretMask)
+	}
+	return text
+}
+
+// maskedError is an error whose message has its secrets masked. It wraps the
+// original error, so the exit code of a command can still be retrieved.
+type maskedError struct {
+	err error
+	msg string
+}
+
+func (e *maskedError) Error() string {
+	return e.msg
+}
+
+func (e *maskedError) Unwrap() error {
+	return e.err
+}
+
+// maskError masks the secrets of the message of err, which can show rendered
+// values like args.
+func (d *Driver) maskError(err error) error {
+	if err == nil {
+		return nil
+	}
+	msg := d.mask(err.Error())
+	if msg == err.Error() {
+		return err
+	}
+	return &maskedError{err: err, msg: msg}
+}
+
+// maskAll masks each element of texts.
+func (d *Driver) maskAll(texts []string) []string {
+	if texts == nil {
+		return nil
+	}
+	masked := make([]string, len(texts))
+	for i, t := range texts {
+		masked[i] = d.mask(t)
+	}
+	return masked
+}
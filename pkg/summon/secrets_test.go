@@ -0,0 +1,135 @@
+package summon
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"testing/fstest"
+
+	"github.com/lithammer/dedent"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/davidovich/summon/internal/testutil"
+	"github.com/davidovich/summon/pkg/config"
+)
+
+var secretsConfig = dedent.Dedent(`
+	secrets:
+	  env: ['SUMMON_TEST_*_TOKEN']
+	  data: [password]
+	  patterns: ['ghp_[A-Za-z0-9]+']
+	exec:
+	  flags:
+	    api-key:
+	      effect: '--api-key={{ .flag }}'
+	      secret: true
+	  handles:
+	    deploy:
+	      cmd: [deploy]
+	      args:
+	        - '--token={{ env "SUMMON_TEST_GH_TOKEN" }}'
+	        - '--password={{ .password }}'
+	        - '{{ arg 0 }}'
+	    second:
+	      cmd: [deploy]
+	      args: ['{{ arg 1 "missing second arg" }}']
+	`)
+
+func makeSecretsDriver(t *testing.T, opts ...Option) (*Driver, *cobra.Command) {
+	t.Setenv("SUMMON_TEST_GH_TOKEN", "s3cr3t")
+
+	testFs := fstest.MapFS{}
+	testFs[config.ConfigFileName] = &fstest.MapFile{Data: []byte(secretsConfig)}
+
+	data := `{"password": "hunter2"}`
+	s, err := New(testFs, append(opts, JSON(&data))...)
+	require.NoError(t, err)
+	root, err := s.ConstructCommandTree(&cobra.Command{Use: "root"}, false)
+	require.NoError(t, err)
+	return s, root
+}
+
+func TestSecretsMaskedInDryRun(t *testing.T) {
+	out := &bytes.Buffer{}
+	s, root := makeSecretsDriver(t, DryRunFormat("json"), Out(out))
+
+	s.Configure(Args("prog", "deploy", "ghp_abc123", "--api-key", "k3y"))
+	s.SetupRunArgs(root)
+	_, err := executeCommand(root)
+	require.NoError(t, err)
+
+	plan := Plan{}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &plan))
+	assert.Equal(t, []string{"deploy", "--token=***", "--password=***", "***", "--api-key=***"}, plan.Argv)
+	assert.Equal(t, []string{"***"}, plan.ConsumedArgs)
+	assert.NotContains(t, out.String(), "s3cr3t")
+}
+
+func TestSecretsMaskedInExplain(t *testing.T) {
+	out := &bytes.Buffer{}
+	s, root := makeSecretsDriver(t, Explain(true), Out(out))
+
+	s.Configure(Args("prog", "deploy", "ghp_abc123", "--api-key", "k3y"))
+	s.SetupRunArgs(root)
+	_, err := executeCommand(root)
+	require.NoError(t, err)
+
+	for _, secret := range []string{"s3cr3t", "hunter2", "ghp_abc123", "k3y"} {
+		assert.NotContains(t, out.String(), secret)
+	}
+	assert.Contains(t, out.String(), `["deploy" "--token=***" "--password=***" "***" "--api-key=***"]`)
+}
+
+func TestSecretsReachTheCommand(t *testing.T) {
+	execCmd := testutil.FakeExecCommand("TestSummonRunHelper")
+	s, root := makeSecretsDriver(t, ExecCmd(execCmd.Fn))
+
+	s.Configure(Args("prog", "deploy", "ghp_abc123", "--api-key", "k3y"))
+	s.SetupRunArgs(root)
+	_, err := executeCommand(root)
+	require.NoError(t, err)
+
+	calls := execCmd.GetCalls()
+	require.Len(t, calls, 1)
+	assert.Equal(t, []string{"deploy", "--token=s3cr3t", "--password=hunter2", "ghp_abc123", "--api-key=k3y"}, calls[0].Args)
+}
+
+func TestSecretsMaskedInErrors(t *testing.T) {
+	s, root := makeSecretsDriver(t, DryRun(true), Out(&bytes.Buffer{}))
+
+	s.Configure(Args("prog", "second", "ghp_abc123"))
+	s.SetupRunArgs(root)
+	_, err := executeCommand(root)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "missing second arg: index 1 out of range, args: [***]")
+	assert.NotContains(t, err.Error(), "ghp_abc123")
+}
+
+func TestMask(t *testing.T) {
+	s, err := newSecrets(config.SecretsSpec{Patterns: []string{`tok_\w+`}})
+	require.NoError(t, err)
+	d := &Driver{inv: &invocation{secrets: s}}
+	s.add("abc", "abcdef", "")
+
+	assert.Equal(t, "x=*** y=*** z=***", d.mask("x=abcdef y=abc z=tok_42"))
+
+	_, err = newSecrets(config.SecretsSpec{Patterns: []string{`(`}})
+	assert.ErrorContains(t, err, "invalid secrets pattern")
+}
+
+func TestMaskNestedData(t *testing.T) {
+	s, err := newSecrets(config.SecretsSpec{Data: []string{".creds.token", "keys", "*.password"}})
+	require.NoError(t, err)
+	d := &Driver{inv: &invocation{secrets: s}}
+	d.opts.data = map[string]interface{}{
+		"creds":  map[string]interface{}{"token": "t0k", "user": "bob"},
+		"keys":   []interface{}{"k1", map[string]interface{}{"name": "k2"}},
+		"db":     map[string]interface{}{"password": "pw"},
+		"user":   "alice",
+		"nested": map[string]interface{}{"creds": map[string]interface{}{"token": "other"}},
+	}
+
+	assert.Equal(t, "*** bob *** *** *** alice other", d.mask("t0k bob k1 k2 pw alice other"))
+}
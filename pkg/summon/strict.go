@@ -0,0 +1,197 @@
+package summon
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"text/template"
+	"text/template/parse"
+)
+
+// lenientFieldFunc is the template function looking up the fields passed to
+// required.
+const lenientFieldFunc = "lenientField"
+
+// strict returns true if templates fail on missing keys instead of rendering
+// an empty string.
+func (d *Driver) strict() bool {
+	return d.opts.strict || d.config.Strict
+}
+
+// missingKey returns the missingkey template option of the invocation.
+func (d *Driver) missingKey() string {
+	if d.strict() {
+		return "missingkey=error"
+	}
+	return "missingkey=zero"
+}
+
+// handleError adds the handle to template execution errors in strict mode,
+// so missing keys can be traced back to the config.
+func (d *Driver) handleError(ref string, err error) error {
+	var execErr template.ExecError
+	if d.strict() && errors.As(err, &execErr) {
+		return fmt.Errorf("handle %s: %w", ref, err)
+	}
+	return err
+}
+
+// required returns val, or an error with msg if val is missing or empty.
+func required(msg string, val interface{}) (interface{}, error) {
+	if val == nil {
+		return nil, errors.New(msg)
+	}
+	if s, ok := val.(string); ok && s == "" {
+		return nil, errors.New(msg)
+	}
+	return val, nil
+}
+
+// lenientField returns the value of the fields chain of receiver, or nil if
+// one of them is missing.
+func lenientField(receiver interface{}, fields ...string) interface{} {
+	v := reflect.ValueOf(receiver)
+	for _, field := range fields {
+		for v.Kind() == reflect.Interface || v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return nil
+			}
+			v = v.Elem()
+		}
+		switch v.Kind() {
+		case reflect.Map:
+			if v.Type().Key().Kind() != reflect.String {
+				return nil
+			}
+			v = v.MapIndex(reflect.ValueOf(field).Convert(v.Type().Key()))
+		case reflect.Struct:
+			v = v.FieldByName(field)
+			if v.IsValid() && !v.CanInterface() {
+				return nil
+			}
+		default:
+			return nil
+		}
+		if !v.IsValid() {
+			return nil
+		}
+	}
+	if !v.IsValid() {
+		return nil
+	}
+	return v.Interface()
+}
+
+// lenientRequired rewrites the fields passed to required (like
+// {{ required "msg" .x }} or {{ .x | required "msg" }}) in the templates of t
+// to lenient lookups, so required reports its message instead of the
+// missing key error of strict mode. The templates are shared by the clones of
+// t, so this is only done when they are loaded.
+func lenientRequired(t *template.Template) {
+	for _, tmpl := range t.Templates() {
+		if tmpl.Tree != nil {
+			rewriteRequired(tmpl.Tree.Root)
+		}
+	}
+}
+
+// parseTemplate parses text in t, and rewrites the required calls of the
+// trees it produced. The trees t shares with the template context are left
+// alone, as they can be executed concurrently by other invocations.
+func parseTemplate(t *template.Template, text string) (*template.Template, error) {
+	shared := map[*parse.Tree]struct{}{}
+	for _, tmpl := range t.Templates() {
+		shared[tmpl.Tree] = struct{}{}
+	}
+	t, err := t.Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	for _, tmpl := range t.Templates() {
+		if _, ok := shared[tmpl.Tree]; !ok && tmpl.Tree != nil {
+			rewriteRequired(tmpl.Tree.Root)
+		}
+	}
+	return t, nil
+}
+
+func rewriteRequired(node parse.Node) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, c := range n.Nodes {
+			rewriteRequired(c)
+		}
+	case *parse.ActionNode:
+		rewriteRequiredPipe(n.Pipe)
+	case *parse.TemplateNode:
+		rewriteRequiredPipe(n.Pipe)
+	case *parse.IfNode:
+		rewriteRequiredBranch(&n.BranchNode)
+	case *parse.RangeNode:
+		rewriteRequiredBranch(&n.BranchNode)
+	case *parse.WithNode:
+		rewriteRequiredBranch(&n.BranchNode)
+	}
+}
+
+func rewriteRequiredBranch(b *parse.BranchNode) {
+	rewriteRequiredPipe(b.Pipe)
+	rewriteRequired(b.List)
+	rewriteRequired(b.ElseList)
+}
+
+func rewriteRequiredPipe(p *parse.PipeNode) {
+	if p == nil {
+		return
+	}
+	for i, cmd := range p.Cmds {
+		for _, arg := range cmd.Args {
+			if pipe, ok := arg.(*parse.PipeNode); ok {
+				rewriteRequiredPipe(pipe)
+			}
+		}
+		if ident, ok := cmd.Args[0].(*parse.IdentifierNode); !ok || ident.Ident != "required" {
+			continue
+		}
+		switch {
+		case len(cmd.Args) == 3:
+			cmd.Args[2] = lenientArg(cmd.Args[2])
+		case len(cmd.Args) == 2 && i > 0 && len(p.Cmds[i-1].Args) == 1:
+			p.Cmds[i-1].Args[0] = lenientArg(p.Cmds[i-1].Args[0])
+		}
+	}
+}
+
+// lenientArg returns a lenientField call looking up the field node arg.
+func lenientArg(arg parse.Node) parse.Node {
+	var receiver parse.Node
+	var fields []string
+	switch a := arg.(type) {
+	case *parse.FieldNode:
+		receiver = &parse.DotNode{Pos: a.Pos}
+		fields = a.Ident
+	case *parse.VariableNode:
+		if len(a.Ident) < 2 {
+			return arg
+		}
+		receiver = &parse.VariableNode{NodeType: parse.NodeVariable, Pos: a.Pos, Ident: a.Ident[:1]}
+		fields = a.Ident[1:]
+	default:
+		return arg
+	}
+
+	pos := arg.Position()
+	args := []parse.Node{parse.NewIdentifier(lenientFieldFunc).SetPos(pos), receiver}
+	for _, f := range fields {
+		args = append(args, &parse.StringNode{NodeType: parse.NodeString, Pos: pos, Quoted: strconv.Quote(f), Text: f})
+	}
+	return &parse.PipeNode{
+		NodeType: parse.NodePipe,
+		Pos:      pos,
+		Cmds:     []*parse.CommandNode{{NodeType: parse.NodeCommand, Pos: pos, Args: args}},
+	}
+}
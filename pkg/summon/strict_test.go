@@ -0,0 +1,130 @@
+package summon
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/lithammer/dedent"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/davidovich/summon/pkg/config"
+)
+
+func TestStrict(t *testing.T) {
+	testFs := fstest.MapFS{
+		"assets/" + config.ConfigFileName: &fstest.MapFile{Data: []byte(dedent.Dedent(`
+			exec:
+			  handles:
+			    typo:
+			      cmd: [echo, '{{ .ModNmae }}']
+			    checked:
+			      cmd: [echo, '{{ required "module name is required" .ModName }}']
+			`))},
+		"assets/gen/go.mod":          &fstest.MapFile{Data: []byte(`module {{ .ModNmae }}`)},
+		"assets/gen/required.txt":    &fstest.MapFile{Data: []byte(`{{ required "module name is required" .ModName }}`)},
+		"assets/gen/pipe.txt":        &fstest.MapFile{Data: []byte(`{{ with .Other }}{{ $.ModName | required "pipe" }}{{ end }}`)},
+		"assets/gen/present.txt":     &fstest.MapFile{Data: []byte(`{{ required "no owner" .Owner.name }}`)},
+		"assets/gen/partial.txt":     &fstest.MapFile{Data: []byte(`{{ template "mod" . }}`)},
+		"assets/_templates/mod.tpl":  &fstest.MapFile{Data: []byte(`{{ define "mod" }}{{ .ModNmae }}{{ end }}`)},
+		"assets/gen/{{ .Name }}.txt": &fstest.MapFile{Data: []byte(`name`)},
+	}
+	data := `{"Other": true, "Owner": {"name": "team"}}`
+	s, err := New(testFs, JSON(&data))
+	require.NoError(t, err)
+
+	summon := func(filename string, opts ...Option) (string, error) {
+		out := &bytes.Buffer{}
+		_, err := s.Summon(append([]Option{Filename(filename), Dest("-"), Out(out)}, opts...)...)
+		return out.String(), err
+	}
+
+	t.Run("lenient", func(t *testing.T) {
+		out, err := summon("gen/go.mod")
+		require.NoError(t, err)
+		assert.Equal(t, "module ", out)
+
+		plan, err := s.Plan(Ref("typo"))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"echo"}, plan.Argv)
+	})
+
+	t.Run("strict-asset", func(t *testing.T) {
+		_, err := summon("gen/go.mod", Strict(true))
+		assert.ErrorContains(t, err, `template: gen/go.mod:1:10: executing "gen/go.mod" at <.ModNmae>: map has no entry for key "ModNmae"`)
+
+		_, err = summon("gen/partial.txt", Strict(true))
+		assert.ErrorContains(t, err, `map has no entry for key "ModNmae"`)
+
+		_, err = summon("gen/{{ .Name }}.txt", Strict(true))
+		assert.ErrorContains(t, err, `map has no entry for key "Name"`)
+	})
+
+	t.Run("strict-handle", func(t *testing.T) {
+		_, err := s.Plan(Ref("typo"), Strict(true))
+		assert.ErrorContains(t, err, `handle typo: template: summon:1:3: executing "summon" at <.ModNmae>: map has no entry for key "ModNmae"`)
+	})
+
+	t.Run("strict-config", func(t *testing.T) {
+		testFs := fstest.MapFS{
+			"assets/" + config.ConfigFileName: &fstest.MapFile{Data: []byte("strict: true\n")},
+			"assets/go.mod":                   &fstest.MapFile{Data: []byte(`module {{ .ModNmae }}`)},
+		}
+		s, err := New(testFs)
+		require.NoError(t, err)
+		_, err = s.Summon(Filename("go.mod"), Dest("-"), Out(&bytes.Buffer{}))
+		assert.ErrorContains(t, err, `map has no entry for key "ModNmae"`)
+	})
+
+	for _, strict := range []bool{false, true} {
+		t.Run("required", func(t *testing.T) {
+			_, err := summon("gen/required.txt", Strict(strict))
+			assert.ErrorContains(t, err, "error calling required: module name is required")
+
+			_, err = summon("gen/pipe.txt", Strict(strict))
+			assert.ErrorContains(t, err, "error calling required: pipe")
+
+			_, err = s.Plan(Ref("checked"), Strict(strict))
+			assert.ErrorContains(t, err, "module name is required")
+
+			out, err := summon("gen/present.txt", Strict(strict))
+			require.NoError(t, err)
+			assert.Equal(t, "team", out)
+		})
+	}
+}
+
+func TestStrictConcurrentRuns(t *testing.T) {
+	testFs := fstest.MapFS{
+		"assets/" + config.ConfigFileName: &fstest.MapFile{Data: []byte(dedent.Dedent(`
+			templates: '{{ define "owner" }}{{ required "owner is required" .owner }}{{ end }}'
+			exec:
+			  handles:
+			    greet:
+			      cmd: [echo, '{{ template "owner" . }}', '{{ template "name" . }}']
+			`))},
+		"assets/_templates/name.tpl": &fstest.MapFile{Data: []byte(`{{ define "name" }}{{ .name | required "name is required" }}{{ end }}`)},
+	}
+	data := `{"owner": "team", "name": "app"}`
+	s, err := New(testFs, JSON(&data), DryRunFormat("json"))
+	require.NoError(t, err)
+
+	const runs = 20
+	errs := make([]error, runs)
+	var wg sync.WaitGroup
+	for i := 0; i < runs; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = s.Run(Ref("greet"), Strict(i%2 == 0), Out(&bytes.Buffer{}))
+		}(i)
+	}
+	wg.Wait()
+
+	for i := 0; i < runs; i++ {
+		assert.NoError(t, errs[i], fmt.Sprint("run ", i))
+	}
+}
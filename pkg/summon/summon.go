@@ -7,12 +7,17 @@ package summon
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"io/fs"
 	"os"
 	"path"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 
 	"github.com/spf13/afero"
 
@@ -32,7 +37,12 @@ func (d *Driver) Summon(opts ...Option) (string, error) {
 	if d == nil {
 		return "", fmt.Errorf("Driver cannot be nil")
 	}
+	defer d.removeEphemeral()
 
+	return d.summon(opts...)
+}
+
+func (d *Driver) summon(opts ...Option) (string, error) {
 	err := d.Configure(opts...)
 	if err != nil {
 		return "", err
@@ -53,8 +63,11 @@ func (d *Driver) Summon(opts ...Option) (string, error) {
 	}
 
 	filename := filepath.Clean(d.opts.filename)
-	filename = d.resolveAlias(filename)
-	filename = path.Join(d.baseDataDir, filename)
+	assetPath := d.resolveAlias(filename)
+	filename = path.Join(d.baseDataDir, assetPath)
+	if d.isPartial(filepath.ToSlash(assetPath)) {
+		return "", fmt.Errorf("%s is a template partial and cannot be summoned", assetPath)
+	}
 
 	embeddedFile, err := d.fs.Open(filename)
 	if err != nil {
@@ -71,11 +84,21 @@ func (d *Driver) Summon(opts ...Option) (string, error) {
 			fs.WalkDir(d.fs, startdir, makeCopyFileFun(startdir, d))
 	}
 
-	return d.copyOneFile(embeddedFile, filename, d.baseDataDir)
+	return d.copyOneFile(embeddedFile, filename, d.baseDataDir, assetPath)
 }
 
 func makeCopyFileFun(startdir string, d *Driver) func(path string, de fs.DirEntry, _ error) error {
 	return func(path string, de fs.DirEntry, _ error) error {
+		rel, err := filepath.Rel(d.baseDataDir, path)
+		if err != nil {
+			return err
+		}
+		if d.isPartial(filepath.ToSlash(rel)) {
+			if de.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
 		if de.IsDir() {
 			return nil
 		}
@@ -83,16 +106,12 @@ func makeCopyFileFun(startdir string, d *Driver) func(path string, de fs.DirEntr
 		if err != nil {
 			return err
 		}
-		rel, err := filepath.Rel(d.baseDataDir, path)
-		if err != nil {
-			return err
-		}
 		subdir, err := filepath.Rel(d.baseDataDir, startdir)
 		if err != nil {
 			return err
 		}
 
-		_, err = d.copyOneFile(file, rel, subdir)
+		_, err = d.copyOneFile(file, rel, subdir, rel)
 		return err
 	}
 }
@@ -105,18 +124,52 @@ func (d *Driver) resolveAlias(alias string) string {
 	return alias
 }
 
-func (d *Driver) copyOneFile(embeddedFile fs.File, filename, root string) (string, error) {
+// fileMode returns the mode of a summoned asset. A matching glob of the modes
+// config has precedence (the longest one wins), then content starting with a
+// shebang is made executable. Globs have the syntax of matchGlob, like the
+// other globs of the config.
+func (d *Driver) fileMode(assetPath, content string) (fs.FileMode, error) {
+	assetPath = filepath.ToSlash(assetPath)
+	var pattern string
+	for glob := range d.config.Modes {
+		if _, err := path.Match(glob, ""); err != nil {
+			return 0, fmt.Errorf("invalid modes glob %q in config %s: %w", glob, config.ConfigFileName, err)
+		}
+		if matchGlob(glob, assetPath) && (len(glob) > len(pattern) || len(glob) == len(pattern) && glob < pattern) {
+			pattern = glob
+		}
+	}
+	if pattern != "" {
+		mode, err := strconv.ParseUint(d.config.Modes[pattern], 8, 32)
+		if err != nil {
+			return 0, fmt.Errorf("invalid mode %q for %q in config %s: %w", d.config.Modes[pattern], pattern, config.ConfigFileName, err)
+		}
+		return fs.FileMode(mode).Perm(), nil
+	}
+
+	if strings.HasPrefix(content, "#!") {
+		return 0o755, nil
+	}
+	return 0o644, nil
+}
+
+func (d *Driver) copyOneFile(embeddedFile fs.File, filename, root, assetPath string) (string, error) {
 	destination := d.opts.destination
 
-	if !d.opts.raw {
-		var err error
-		filename, err = d.renderTemplate(filename)
+	rule, err := d.renderRule(assetPath)
+	if err != nil {
+		return "", err
+	}
+	raw := d.opts.raw || rule.Raw || !d.rendered(assetPath)
+	if !raw {
+		filename, err = d.renderAsset(filepath.ToSlash(assetPath), filename, d.opts.data, rule.Delims)
 		if err != nil {
 			return "", err
 		}
+		filename = strings.TrimSuffix(filename, d.config.Render.Suffix)
 	}
 
-	filename, err := filepath.Rel(root, filename)
+	filename, err = filepath.Rel(root, filename)
 	if err != nil {
 		return "", err
 	}
@@ -146,10 +199,13 @@ func (d *Driver) copyOneFile(embeddedFile fs.File, filename, root string) (strin
 	}
 
 	var rendered string
-	if d.opts.raw || filename == config.ConfigFileName {
+	if raw || filename == config.ConfigFileName {
 		rendered = string(fileContent)
 	} else {
-		rendered, err = d.renderTemplate(string(fileContent))
+		includes := d.inv.includes
+		d.inv.includes = append(slices.Clip(includes), filepath.ToSlash(assetPath))
+		rendered, err = d.renderAsset(filepath.ToSlash(assetPath), string(fileContent), d.opts.data, rule.Delims)
+		d.inv.includes = includes
 		if err != nil {
 			return "", err
 		}
@@ -160,9 +216,106 @@ func (d *Driver) copyOneFile(embeddedFile fs.File, filename, root string) (strin
 		return "", err
 	}
 
+	if summonedFile != "" {
+		mode, err := d.fileMode(assetPath, rendered)
+		if err != nil {
+			return "", err
+		}
+		err = appFs.Chmod(summonedFile, mode)
+		if err != nil {
+			return "", err
+		}
+	}
+
 	return summonedFile, nil
 }
 
+// ephemeralSummonDir returns the private directory of the current invocation
+// where the summon template function writes files. It is created on first use
+// and removed by removeEphemeral.
+func (d *Driver) ephemeralSummonDir() (string, error) {
+	if d.inv.ephemeralDir == "" {
+		dir, err := afero.TempDir(appFs, "", filepath.Base(Name)+"-")
+		if err != nil {
+			return "", err
+		}
+		d.inv.ephemeralDir = dir
+	}
+	return d.inv.ephemeralDir, nil
+}
+
+// removeEphemeral removes the files summoned by the summon template function
+// during the current invocation.
+func (d *Driver) removeEphemeral() {
+	if d.inv.ephemeralDir == "" {
+		return
+	}
+	appFs.RemoveAll(d.inv.ephemeralDir)
+	d.inv.ephemeralDir = ""
+}
+
+// summonKeep summons filename in a content addressed cache directory so it
+// persists after the invocation. Same rendered content yields the same path,
+// which makes it safe for concurrent users.
+func (d *Driver) summonKeep(filename string) (string, error) {
+	cache, err := cacheDir()
+	if err != nil {
+		return "", err
+	}
+	cacheRoot := filepath.Join(cache, "summoned")
+	err = appFs.MkdirAll(cacheRoot, 0o700)
+	if err != nil {
+		return "", err
+	}
+
+	// stage on the same filesystem as the cache so it can be renamed in place
+	staging, err := afero.TempDir(appFs, cacheRoot, "staging-")
+	if err != nil {
+		return "", err
+	}
+	defer appFs.RemoveAll(staging)
+
+	summoned, err := d.summon(Filename(filename), Dest(staging))
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(staging, summoned)
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.New()
+	err = afero.Walk(appFs, staging, func(path string, info fs.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		content, err := afero.ReadFile(appFs, path)
+		if err != nil {
+			return err
+		}
+		p, _ := filepath.Rel(staging, path)
+		fmt.Fprintf(hash, "%s\x00%s\x00%d\x00", filepath.ToSlash(p), info.Mode().Perm(), len(content))
+		hash.Write(content)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	target := filepath.Join(cacheRoot, hex.EncodeToString(hash.Sum(nil))[:16])
+	if exists, _ := afero.DirExists(appFs, target); !exists {
+		// a concurrent summon of the same content may win the rename, which
+		// is fine as the content is the same.
+		if err := appFs.Rename(staging, target); err != nil {
+			if exists, _ := afero.DirExists(appFs, target); !exists {
+				return "", err
+			}
+		}
+	}
+
+	return filepath.Join(target, rel), nil
+}
+
 func init() {
 	testutil.SetFs = func(fs afero.Fs) { appFs = fs }
 	testutil.GetFs = func() afero.Fs { return appFs }
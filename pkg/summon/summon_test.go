@@ -3,6 +3,7 @@ package summon
 import (
 	"bytes"
 	"embed"
+	"io/fs"
 	"path/filepath"
 	"testing"
 	"testing/fstest"
@@ -95,6 +96,45 @@ func TestSubfolderHierarchy(t *testing.T) {
 	a.Equal("b content", string(bytes))
 }
 
+func TestSummonedFileModes(t *testing.T) {
+	defer testutil.ReplaceFs()()
+
+	testFs := fstest.MapFS{}
+	testFs["assets/"+config.ConfigFileName] = &fstest.MapFile{Data: []byte(`
+modes:
+  "*.py": "0750"
+  "bin/*": "0700"
+  "bin/tool.py": "0755"
+  "lib/**/*.so": "0555"
+`)}
+	testFs["assets/hello.sh"] = &fstest.MapFile{Data: []byte("#!/bin/bash\necho hello")}
+	testFs["assets/script.py"] = &fstest.MapFile{Data: []byte("print('hello')")}
+	testFs["assets/bin/tool"] = &fstest.MapFile{Data: []byte("#!/bin/sh")}
+	testFs["assets/bin/tool.py"] = &fstest.MapFile{Data: []byte("")}
+	testFs["assets/readme.txt"] = &fstest.MapFile{Data: []byte("readme")}
+	testFs["assets/lib/a/b/c.so"] = &fstest.MapFile{Data: []byte("")}
+
+	s, err := New(testFs, All(true), Dest("o"))
+	assert.NoError(t, err)
+
+	_, err = s.Summon()
+	assert.NoError(t, err)
+
+	for file, mode := range map[string]fs.FileMode{
+		"o/hello.sh":     0o755, // shebang
+		"o/script.py":    0o750, // base name glob
+		"o/bin/tool":     0o700, // path glob has precedence over shebang
+		"o/bin/tool.py":  0o755, // longest glob wins
+		"o/readme.txt":   0o644,
+		"o/lib/a/b/c.so": 0o555, // ** matches directories
+	} {
+		stat, err := appFs.Stat(file)
+		if assert.NoError(t, err) {
+			assert.Equal(t, mode, stat.Mode().Perm(), file)
+		}
+	}
+}
+
 func TestSummonScenarios(t *testing.T) {
 	defer testutil.ReplaceFs()()
 	assert := assert.New(t)
@@ -2,14 +2,20 @@ package summon
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"maps"
 	"os"
+	"os/exec"
 	"reflect"
 	"strings"
 	"text/template"
 
 	"github.com/Masterminds/sprig/v3"
 	"github.com/cqroot/prompt"
+	"gopkg.in/yaml.v3"
 )
 
 func (d *Driver) prepareTemplate() (*template.Template, error) {
@@ -25,9 +31,13 @@ func (d *Driver) prepareTemplate() (*template.Template, error) {
 		}
 	}
 
-	t.Option("missingkey=zero").
+	t.Option(d.missingKey()).
 		Funcs(sprig.TxtFuncMap()).
 		Funcs(summonFuncMap(d))
+	// partials keep the option they were parsed with
+	for _, tmpl := range t.Templates() {
+		tmpl.Option(d.missingKey())
+	}
 
 	return t, nil
 }
@@ -43,75 +53,82 @@ func executeTemplate(t *template.Template, data interface{}) (string, error) {
 }
 
 func (d *Driver) renderTemplate(tmpl string) (string, error) {
+	return d.renderTemplateData(tmpl, d.opts.data)
+}
+
+// renderTemplateData renders tmpl with data instead of the invocation data.
+func (d *Driver) renderTemplateData(tmpl string, data map[string]interface{}) (string, error) {
 	t, err := d.prepareTemplate()
 	if err != nil {
 		return tmpl, err
 	}
 
-	t, err = t.Parse(tmpl)
+	t, err = parseTemplate(t, tmpl)
 	if err != nil {
 		return tmpl, err
 	}
 
-	data := d.opts.data
 	return executeTemplate(t, data)
 }
 
 func summonFuncMap(d *Driver) template.FuncMap {
 	initConsumed := func() {
-		if d.opts.argsConsumed == nil {
-			d.opts.argsConsumed = make(map[int]struct{}, len(d.opts.args))
+		if d.inv.argsConsumed == nil {
+			d.inv.argsConsumed = make(map[int]struct{}, len(d.opts.args))
 		}
 	}
 	consumeAllArgs := func() {
 		initConsumed()
 		for i := range d.opts.args {
-			d.opts.argsConsumed[i] = struct{}{}
+			d.inv.argsConsumed[i] = struct{}{}
 		}
 	}
-	return template.FuncMap{
-		"run": func(args ...string) (string, error) {
-			driverCopy := Driver{
-				opts:        d.opts,
-				config:      d.config,
-				fs:          d.fs,
-				baseDataDir: d.baseDataDir,
-				templateCtx: d.templateCtx,
-				execCommand: d.execCommand,
-				configRead:  d.configRead,
-				cmdToSpec:   d.cmdToSpec,
-				prompts:     d.prompts,
-				prompter:    d.prompter,
-			}
-			driverCopy.opts.argsConsumed = map[int]struct{}{}
-			driverCopy.opts.cobraCmd = nil
-			driverCopy.opts.helpWanted.helpFlag = ""
-
-			b := &strings.Builder{}
-			err := driverCopy.Run(Ref(args[0]), Args(args[1:]...), Out(b))
-
-			if d.opts.dryrun {
-				b.WriteString("[")
-				b.WriteString(args[0])
-				b.WriteString(" (dry-run)]")
-			}
-
-			if d.opts.debug {
-				fmt.Fprintf(os.Stderr, "Output [%s] -> `%s`...\n", args[0], b)
+	funcs := template.FuncMap{
+		"run":       d.runNested,
+		"runResult": d.runResult,
+		"runJSON": func(args ...string) (interface{}, error) {
+			return d.runParsed("json", json.Unmarshal, args...)
+		},
+		"runYAML": func(args ...string) (interface{}, error) {
+			return d.runParsed("yaml", yaml.Unmarshal, args...)
+		},
+		"runLines": func(args ...string) ([]string, error) {
+			out, err := d.runNested(args...)
+			if err != nil || out == "" || d.opts.dryrun {
+				return []string{}, err
 			}
-			return strings.TrimSpace(b.String()), err
+			return strings.Split(out, "\n"), nil
 		},
 		"summon": func(path string, arg ...any) (string, error) {
-			dest := os.TempDir()
+			dest := ""
 			if len(arg) > 0 {
 				if reflect.TypeOf(arg[0]).Kind() == reflect.String {
 					dest = arg[0].(string)
 				}
 			}
-			return d.Summon(Filename(path), Dest(dest))
+			if dest == "-" {
+				// return the rendered content instead of a path
+				out := d.opts.out
+				defer func() { d.opts.out = out }()
+
+				b := &strings.Builder{}
+				_, err := d.summon(Filename(path), Dest(dest), Out(b))
+				return b.String(), err
+			}
+			if dest == "" {
+				var err error
+				dest, err = d.ephemeralSummonDir()
+				if err != nil {
+					return "", err
+				}
+			}
+			return d.summon(Filename(path), Dest(dest))
+		},
+		"summonKeep": func(path string) (string, error) {
+			return d.summonKeep(path)
 		},
 		"flagValue": func(flag string) (string, error) {
-			for _, toRender := range d.flagsToRender {
+			for _, toRender := range d.inv.flags {
 				if toRender.name == flag {
 					toRender.explicit = true
 					return toRender.renderTemplate()
@@ -130,7 +147,7 @@ func summonFuncMap(d *Driver) template.FuncMap {
 
 			retrieved := d.opts.args[index]
 			initConsumed()
-			d.opts.argsConsumed[index] = struct{}{}
+			d.inv.argsConsumed[index] = struct{}{}
 			return retrieved, nil
 		},
 		"args": func() []string {
@@ -154,6 +171,8 @@ func summonFuncMap(d *Driver) template.FuncMap {
 				for _, e := range t {
 					selectors = append(selectors, e.(string))
 				}
+			case []string:
+				selectors = t
 			default:
 				return "", fmt.Errorf("last parameter should be a default value or a list of choices")
 			}
@@ -171,17 +190,157 @@ func summonFuncMap(d *Driver) template.FuncMap {
 			}
 
 			// record result for future use
-			d.prompts[slot] = result
+			d.inv.prompts[slot] = result
 			return result, nil
 		},
 		"promptValue": func(slot string) (string, error) {
-			p, ok := d.prompts[slot]
+			p, ok := d.inv.prompts[slot]
 			if !ok {
 				return "", fmt.Errorf("no previous prompts were filled for slot '%s'", slot)
 			}
 			return p, nil
 		},
 	}
+	funcs["include"] = d.include
+	funcs["required"] = required
+	funcs[lenientFieldFunc] = lenientField
+	maps.Copy(funcs, fileFuncs(d))
+	return funcs
+}
+
+// stderrTailLines is the number of stderr lines of a failed run call shown in
+// its error.
+const stderrTailLines = 10
+
+// nestedRun is the result of a run template function call.
+type nestedRun struct {
+	stdout string
+	stderr string
+}
+
+// runNested runs the handle args[0] with args[1:] as its args and returns its
+// trimmed output. On failure, the tail of the handle stderr is included in the
+// error.
+func (d *Driver) runNested(args ...string) (string, error) {
+	r, err := d.runCaptured(args...)
+	if err != nil {
+		if tail := stderrTail(r.stderr); tail != "" {
+			return r.stdout, fmt.Errorf("run [%s] failed: %w, stderr:\n%s", args[0], err, tail)
+		}
+		return r.stdout, fmt.Errorf("run [%s] failed: %w", args[0], err)
+	}
+	return r.stdout, nil
+}
+
+// runResult runs a handle like runNested, but does not fail when the handle
+// exits with a non-zero code. It returns the stdout, stderr and exitCode of
+// the handle.
+func (d *Driver) runResult(args ...string) (map[string]interface{}, error) {
+	r, err := d.runCaptured(args...)
+	exitCode := 0
+	if err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return nil, fmt.Errorf("run [%s] failed: %w", args[0], err)
+		}
+		exitCode = exitErr.ExitCode()
+	}
+	return map[string]interface{}{
+		"stdout":   r.stdout,
+		"stderr":   r.stderr,
+		"exitCode": exitCode,
+	}, nil
+}
+
+// nestedDriver returns a driver running handles on behalf of d, like run
+// template function calls and hooks. Its invocation shares the prompts and
+// secrets of the invocation of d.
+func (d *Driver) nestedDriver() *Driver {
+	driverCopy := *d
+	// the nested handle data, like its env files, must not leak in the caller
+	driverCopy.opts.data = maps.Clone(d.opts.data)
+	driverCopy.opts.cobraCmd = nil
+	driverCopy.opts.helpWanted.helpFlag = ""
+	driverCopy.opts.exec = false
+	driverCopy.opts.explain = false
+	driverCopy.inv = &invocation{
+		ctx:          d.inv.ctx,
+		argsConsumed: map[int]struct{}{},
+		prompts:      d.inv.prompts,
+		secrets:      d.inv.secrets,
+		nested:       true,
+	}
+	return &driverCopy
+}
+
+// runCaptured runs a handle and captures its output.
+func (d *Driver) runCaptured(args ...string) (nestedRun, error) {
+	driverCopy := d.nestedDriver()
+
+	b := &strings.Builder{}
+	stderr := &bytes.Buffer{}
+	// the stderr is kept for the error of a failed call
+	driverCopy.opts.errOut = io.MultiWriter(stderr, d.stderr())
+	if d.opts.quietStderr {
+		driverCopy.opts.errOut = stderr
+	}
+	err := driverCopy.run(Ref(args[0]), Args(args[1:]...), Out(b))
+
+	if d.opts.dryrun {
+		d.inv.nestedPlans = append(d.inv.nestedPlans, driverCopy.inv.lastPlan)
+		if d.opts.dryRunFormat == dryRunSh {
+			b.WriteString(runMarker(len(d.inv.nestedPlans) - 1))
+		} else {
+			b.WriteString("[")
+			b.WriteString(args[0])
+			b.WriteString(" (dry-run)]")
+		}
+	}
+
+	if d.opts.debug {
+		fmt.Fprintf(d.stderr(), "Output [%s] -> `%s`...\n", args[0], d.mask(b.String()))
+	}
+	return nestedRun{stdout: strings.TrimSpace(b.String()), stderr: stderr.String()}, err
+}
+
+// stdin returns the stdin of commands.
+func (d *Driver) stdin() io.Reader {
+	if d.opts.in != nil {
+		return d.opts.in
+	}
+	return os.Stdin
+}
+
+// stderr returns where the stderr of commands is written.
+func (d *Driver) stderr() io.Writer {
+	if d.opts.errOut != nil {
+		return d.opts.errOut
+	}
+	return os.Stderr
+}
+
+// stderrTail returns the last lines of stderr.
+func stderrTail(stderr string) string {
+	lines := strings.Split(strings.TrimRight(stderr, "\n"), "\n")
+	if len(lines) > stderrTailLines {
+		lines = lines[len(lines)-stderrTailLines:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// runParsed runs a handle like runNested and parses its output with unmarshal.
+// Nothing is parsed in dry-run as the handle is not run.
+func (d *Driver) runParsed(format string, unmarshal func([]byte, any) error, args ...string) (interface{}, error) {
+	out, err := d.runNested(args...)
+	if err != nil || d.opts.dryrun {
+		return nil, err
+	}
+	var parsed interface{}
+	err = unmarshal([]byte(out), &parsed)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse the output of [%s] as %s: %w", args[0], format, err)
+	}
+	return parsed, nil
 }
 
 type Prompt struct {
@@ -5,7 +5,12 @@ import (
 	"testing"
 	"testing/fstest"
 
+	"github.com/lithammer/dedent"
+	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/davidovich/summon/pkg/config"
 )
 
 type testPrompter struct {
@@ -59,4 +64,139 @@ func TestPrompt(t *testing.T) {
 	promptVal, err = s.renderTemplate(`{{ promptValue "continue" }}`)
 	assert.NoError(t, err)
 	assert.Equal(t, "B", promptVal)
+
+	// choices can be a list of strings, like the output of runLines
+	s.opts.data["choices"] = []string{"X", "Y"}
+	ret, err = s.renderTemplate(`{{ prompt "lines" "Select One" .choices }}`)
+	assert.NoError(t, err)
+	assert.Equal(t, "Y", ret)
+}
+
+func TestRunParsed(t *testing.T) {
+	parsedConfig := dedent.Dedent(`
+		exec:
+		  handles:
+		    print: [printf, '%s']
+		`)
+	testCases := []struct {
+		desc      string
+		tmpl      string
+		want      string
+		wantError string
+	}{
+		{
+			desc: "json",
+			tmpl: `{{ range (runJSON "print" "{\"items\": [{\"name\": \"a\"}, {\"name\": \"b\"}]}").items }}{{ .name }};{{ end }}`,
+			want: "a;b;",
+		},
+		{
+			desc: "yaml",
+			tmpl: `{{ index (runYAML "print" "ns: {prod: 3}") "ns" "prod" }}`,
+			want: "3",
+		},
+		{
+			desc: "lines",
+			tmpl: `{{ range runLines "print" "a\nb\n" }}[{{ . }}]{{ end }}`,
+			want: "[a][b]",
+		},
+		{
+			desc: "empty-lines",
+			tmpl: `{{ len (runLines "print" "") }}`,
+			want: "0",
+		},
+		{
+			desc:      "json-error-names-handle",
+			tmpl:      `{{ runJSON "print" "not json" }}`,
+			wantError: "could not parse the output of [print] as json",
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			testFs := fstest.MapFS{}
+			testFs[config.ConfigFileName] = &fstest.MapFile{Data: []byte(parsedConfig)}
+
+			s, err := New(testFs)
+			require.NoError(t, err)
+			_, err = s.ConstructCommandTree(&cobra.Command{Use: "root"}, false)
+			require.NoError(t, err)
+
+			rendered, err := s.renderTemplate(tC.tmpl)
+			if tC.wantError != "" {
+				assert.ErrorContains(t, err, tC.wantError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tC.want, rendered)
+		})
+	}
+}
+
+func TestRunStderr(t *testing.T) {
+	stderrConfig := dedent.Dedent(`
+		exec:
+		  handles:
+		    fail: [sh, -c, 'echo out; for i in 1 2 3 4 5 6 7 8 9 10 11; do echo err$i >&2; done; exit 3']
+		    succeed: [sh, -c, 'echo out; echo warn >&2']
+		`)
+	testCases := []struct {
+		desc        string
+		tmpl        string
+		quietStderr bool
+		want        string
+		wantStderr  string
+		wantError   string
+	}{
+		{
+			desc:        "run-result-does-not-fail",
+			tmpl:        `{{ $r := runResult "fail" }}{{ $r.exitCode }} {{ $r.stdout }} {{ len (splitList "\n" (trim $r.stderr)) }}`,
+			quietStderr: true,
+			want:        "3 out 11",
+		},
+		{
+			desc:       "run-result-success",
+			tmpl:       `{{ $r := runResult "succeed" }}{{ $r.exitCode }} {{ $r.stdout }} {{ trim $r.stderr }}`,
+			want:       "0 out warn",
+			wantStderr: "warn\n",
+		},
+		{
+			desc:      "run-error-has-stderr-tail",
+			tmpl:      `{{ run "fail" }}`,
+			wantError: "run [fail] failed: exit status 3, stderr:\nerr2\nerr3\nerr4\nerr5\nerr6\nerr7\nerr8\nerr9\nerr10\nerr11",
+		},
+		{
+			desc:       "stderr-is-live",
+			tmpl:       `{{ run "succeed" }}`,
+			want:       "out",
+			wantStderr: "warn\n",
+		},
+		{
+			desc:        "quiet-stderr",
+			tmpl:        `{{ run "succeed" }}`,
+			quietStderr: true,
+			want:        "out",
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			testFs := fstest.MapFS{}
+			testFs[config.ConfigFileName] = &fstest.MapFile{Data: []byte(stderrConfig)}
+
+			s, err := New(testFs, QuietStderr(tC.quietStderr))
+			require.NoError(t, err)
+			_, err = s.ConstructCommandTree(&cobra.Command{Use: "root"}, false)
+			require.NoError(t, err)
+			stderr := &bytes.Buffer{}
+			s.opts.errOut = stderr
+
+			rendered, err := s.renderTemplate(tC.tmpl)
+			if tC.wantError != "" {
+				assert.ErrorContains(t, err, tC.wantError)
+				assert.NotContains(t, err.Error(), "err1\n")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tC.want, rendered)
+			assert.Equal(t, tC.wantStderr, stderr.String())
+		})
+	}
 }
@@ -0,0 +1,185 @@
+package summon
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/spf13/afero"
+
+	"github.com/davidovich/summon/pkg/config"
+)
+
+// Tool describes a pinned go tool of a handle.
+type Tool struct {
+	// Handle is the handle (and sub-command) path using the tool
+	Handle string
+	// Module is the package path of the tool
+	Module string
+	// Version of the tool
+	Version string
+	// Path is where the tool binary is cached
+	Path string
+	// Installed is true if the tool binary is in the cache
+	Installed bool
+}
+
+var majorVersionSuffix = regexp.MustCompile(`^v[0-9]+$`)
+
+// cacheDir returns the summon cache directory of this executable.
+func cacheDir() (string, error) {
+	userCache, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(userCache, filepath.Base(Name)), nil
+}
+
+// goToolDir returns the directory where a go tool version is installed.
+func goToolDir(tool *config.GoToolSpec) (string, error) {
+	cache, err := cacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cache, "bin", filepath.FromSlash(tool.Module)+"@"+tool.Version), nil
+}
+
+// goToolBinary returns the path of the binary that go install produces for
+// tool, following the go command naming of major version suffixed packages.
+func goToolBinary(tool *config.GoToolSpec) (string, error) {
+	dir, err := goToolDir(tool)
+	if err != nil {
+		return "", err
+	}
+	elem := path.Base(tool.Module)
+	if majorVersionSuffix.MatchString(elem) && path.Dir(tool.Module) != "." {
+		elem = path.Base(path.Dir(tool.Module))
+	}
+	if runtime.GOOS == "windows" {
+		elem += ".exe"
+	}
+	return filepath.Join(dir, elem), nil
+}
+
+// ensureGoTool returns the cached binary of tool, installing it first if it
+// is not in the cache. In dry-run mode, the tool is not installed.
+func (d *Driver) ensureGoTool(tool *config.GoToolSpec) (string, error) {
+	if tool.Module == "" || tool.Version == "" {
+		return "", fmt.Errorf("go tool needs a module and a version")
+	}
+	bin, err := goToolBinary(tool)
+	if err != nil {
+		return "", err
+	}
+	if installed, _ := afero.Exists(appFs, bin); installed || d.opts.dryrun {
+		return bin, nil
+	}
+
+	cmd := d.execCommand("go", "install", tool.Module+"@"+tool.Version)
+	cmd.Env = append(os.Environ(), "GOBIN="+filepath.Dir(bin))
+	// keep stdout for the tool output
+	cmd.Stdout = d.stderr()
+	cmd.Stderr = d.stderr()
+	if d.opts.debug {
+		fmt.Fprintf(d.stderr(), "Installing [%s@%s] -> `%s`...\n", tool.Module, tool.Version, filepath.Dir(bin))
+	}
+	err = d.runCmd(cmd)
+	if err != nil {
+		return "", fmt.Errorf("could not install go tool %s@%s: %w", tool.Module, tool.Version, err)
+	}
+	return bin, nil
+}
+
+// Tools lists the go tools used by the handles. Sub-commands inheriting the
+// tool of their parent are not listed again.
+func (d *Driver) Tools() ([]Tool, error) {
+	_, handles, err := d.execContext()
+	if err != nil {
+		return nil, err
+	}
+
+	var tools []Tool
+	var collect func(name string, spec *commandSpec, inherited *config.GoToolSpec) error
+	collect = func(name string, spec *commandSpec, inherited *config.GoToolSpec) error {
+		if spec.goTool != nil && spec.goTool != inherited {
+			bin, err := goToolBinary(spec.goTool)
+			if err != nil {
+				return err
+			}
+			installed, _ := afero.Exists(appFs, bin)
+			tools = append(tools, Tool{
+				Handle:    name,
+				Module:    spec.goTool.Module,
+				Version:   spec.goTool.Version,
+				Path:      bin,
+				Installed: installed,
+			})
+		}
+		for sub, subSpec := range spec.subCmd {
+			if err := collect(name+" "+sub, subSpec, spec.goTool); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for name, spec := range handles {
+		if err := collect(name, spec, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Slice(tools, func(i, j int) bool { return tools[i].Handle < tools[j].Handle })
+	return tools, nil
+}
+
+// PruneTools removes the cached go tools that are not used by the handles, or
+// all cached go tools if all is true. It returns the removed directories.
+func (d *Driver) PruneTools(all bool) ([]string, error) {
+	tools, err := d.Tools()
+	if err != nil {
+		return nil, err
+	}
+	used := map[string]struct{}{}
+	for _, t := range tools {
+		used[filepath.Dir(t.Path)] = struct{}{}
+	}
+
+	cache, err := cacheDir()
+	if err != nil {
+		return nil, err
+	}
+	binDir := filepath.Join(cache, "bin")
+	if exists, _ := afero.DirExists(appFs, binDir); !exists {
+		return nil, nil
+	}
+
+	var pruned []string
+	err = afero.Walk(appFs, binDir, func(p string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() || !strings.Contains(info.Name(), "@") {
+			return nil
+		}
+		if _, ok := used[p]; !ok || all {
+			pruned = append(pruned, p)
+		}
+		return filepath.SkipDir
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range pruned {
+		if err := appFs.RemoveAll(p); err != nil {
+			return nil, err
+		}
+	}
+	return pruned, nil
+}
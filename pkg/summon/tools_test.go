@@ -0,0 +1,116 @@
+package summon
+
+import (
+	"bytes"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/lithammer/dedent"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/davidovich/summon/internal/testutil"
+	"github.com/davidovich/summon/pkg/command"
+	"github.com/davidovich/summon/pkg/config"
+)
+
+var goToolConfig = dedent.Dedent(`
+	exec:
+	  handles:
+	    gohack:
+	      go: {module: github.com/rogpeppe/gohack, version: v1.0.2}
+	      subCmd:
+	        get: [get]
+	        tools:
+	          go: {module: golang.org/x/tools/cmd/stringer, version: v0.1.0}
+	    stringer:
+	      go: {module: golang.org/x/tools/cmd/stringer, version: v0.1.0}
+	`)
+
+func TestGoToolHandles(t *testing.T) {
+	defer testutil.ReplaceFs()()
+	t.Setenv("XDG_CACHE_HOME", "/cache")
+
+	testFs := fstest.MapFS{}
+	testFs[config.ConfigFileName] = &fstest.MapFile{Data: []byte(goToolConfig)}
+
+	var calls [][]string
+	s, err := New(testFs, ExecCmd(func(c string, args ...string) *command.Cmd {
+		cmd := &command.Cmd{Cmd: &exec.Cmd{}}
+		cmd.Run = func() error {
+			calls = append(calls, append([]string{c}, args...))
+			if c == "go" {
+				// simulate go install in GOBIN
+				for _, e := range cmd.Env {
+					if gobin, ok := strings.CutPrefix(e, "GOBIN="); ok {
+						return afero.WriteFile(appFs, filepath.Join(gobin, "gohack"), []byte{}, 0o755)
+					}
+				}
+			}
+			return nil
+		}
+		return cmd
+	}))
+	require.NoError(t, err)
+	root, err := s.ConstructCommandTree(&cobra.Command{Use: "root"}, false)
+	require.NoError(t, err)
+
+	bin := filepath.Join("/cache", "summon", "bin", "github.com", "rogpeppe", "gohack@v1.0.2", "gohack")
+
+	t.Run("installed-on-first-use", func(t *testing.T) {
+		stderr := &bytes.Buffer{}
+		err = s.Run(Ref("gohack"), Args("status"), Debug(true), Err(stderr))
+		assert.NoError(t, err)
+		assert.Equal(t, [][]string{
+			{"go", "install", "github.com/rogpeppe/gohack@v1.0.2"},
+			{bin, "status"},
+		}, calls)
+		assert.Contains(t, stderr.String(), "Installing [github.com/rogpeppe/gohack@v1.0.2]")
+	})
+
+	t.Run("cached-thereafter", func(t *testing.T) {
+		calls = nil
+		cmd, args, err := root.Find([]string{"gohack", "get", "a/module"})
+		require.NoError(t, err)
+		err = s.Run(CobraCmd(cmd), Args(args...))
+		assert.NoError(t, err)
+		assert.Equal(t, [][]string{{bin, "get", "a/module"}}, calls)
+	})
+
+	t.Run("list", func(t *testing.T) {
+		tools, err := s.Tools()
+		assert.NoError(t, err)
+		// gohack get inherits the tool of gohack and is not listed
+		require.Len(t, tools, 3)
+		assert.Equal(t, Tool{Handle: "gohack", Module: "github.com/rogpeppe/gohack", Version: "v1.0.2", Path: bin, Installed: true}, tools[0])
+		assert.Equal(t, "gohack tools", tools[1].Handle)
+		assert.Equal(t, "stringer", tools[2].Handle)
+		assert.False(t, tools[2].Installed)
+	})
+
+	t.Run("prune", func(t *testing.T) {
+		orphan := filepath.Join("/cache", "summon", "bin", "github.com", "rogpeppe", "gohack@v1.0.1")
+		require.NoError(t, appFs.MkdirAll(orphan, 0o755))
+
+		pruned, err := s.PruneTools(false)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{orphan}, pruned)
+
+		pruned, err = s.PruneTools(true)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{filepath.Dir(bin)}, pruned)
+	})
+}
+
+func TestGoToolBinary(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", "/cache")
+
+	bin, err := goToolBinary(&config.GoToolSpec{Module: "example.com/tool/v2", Version: "v2.0.0"})
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join("/cache", "summon", "bin", "example.com", "tool", "v2@v2.0.0", "tool"), bin)
+}
@@ -0,0 +1,287 @@
+package summon
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/davidovich/summon/pkg/config"
+)
+
+const defaultDebounce = 300 * time.Millisecond
+
+// watchPollInterval is the interval at which watched files are checked.
+var watchPollInterval = 500 * time.Millisecond
+
+// defaultWatchSkip are the names of directories that are not walked, as they
+// are big and rarely edited, unless a watched glob starts inside one of them
+// (like vendor/**).
+var defaultWatchSkip = []string{".git", "node_modules", "vendor"}
+
+// fileStamp identifies a version of a watched file.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// watcher polls the files matching globs for changes.
+type watcher struct {
+	paths  []string
+	ignore []string
+	files  map[string]fileStamp
+}
+
+// watchSpec merges the watch options with the watch config of the handle. It
+// returns nil when nothing is watched.
+func (d *Driver) watchSpec(cmdSpec *commandSpec) (*config.WatchSpec, time.Duration, error) {
+	spec := config.WatchSpec{}
+	if cmdSpec.watch != nil {
+		spec = *cmdSpec.watch
+	}
+	if len(d.opts.watch) != 0 {
+		spec.Paths = d.opts.watch
+	}
+	// copy the ignore globs of the config, shared by concurrent invocations
+	spec.Ignore = append(append([]string{}, spec.Ignore...), d.opts.watchIgnore...)
+	if len(spec.Paths) == 0 {
+		return nil, 0, nil
+	}
+
+	debounce := defaultDebounce
+	if spec.Debounce != "" {
+		var err error
+		debounce, err = time.ParseDuration(spec.Debounce)
+		if err != nil {
+			return nil, 0, fmt.Errorf("invalid watch debounce %q in config %s: %w", spec.Debounce, config.ConfigFileName, err)
+		}
+	}
+	return &spec, debounce, nil
+}
+
+func newWatcher(spec *config.WatchSpec) *watcher {
+	w := &watcher{
+		paths:  spec.Paths,
+		ignore: spec.Ignore,
+	}
+	w.files = w.scan()
+	return w
+}
+
+// globRoots returns the directories to walk to find the files matching globs:
+// the directories before the first wildcard of each glob. Globs without a /
+// match base names, so the whole current directory is walked for them.
+func globRoots(globs []string) []string {
+	var roots []string
+	for _, g := range globs {
+		root := "."
+		if strings.Contains(g, "/") {
+			var static []string
+			for _, segment := range strings.Split(g, "/") {
+				if strings.ContainsAny(segment, `*?[\`) {
+					break
+				}
+				static = append(static, segment)
+			}
+			if len(static) != 0 {
+				root = path.Join(static...)
+			}
+		}
+		roots = append(roots, root)
+	}
+
+	if slices.Contains(roots, ".") {
+		return []string{"."}
+	}
+	// keep the outermost roots
+	sort.Strings(roots)
+	var outermost []string
+	for _, root := range roots {
+		nested := slices.ContainsFunc(outermost, func(o string) bool {
+			return root == o || strings.HasPrefix(root, o+"/")
+		})
+		if !nested {
+			outermost = append(outermost, root)
+		}
+	}
+	return outermost
+}
+
+// scan stamps the watched files under the roots of the watched globs.
+func (w *watcher) scan() map[string]fileStamp {
+	files := map[string]fileStamp{}
+	for _, root := range globRoots(w.paths) {
+		w.scanRoot(root, files)
+	}
+	return files
+}
+
+func (w *watcher) scanRoot(root string, files map[string]fileStamp) {
+	afero.Walk(appFs, filepath.FromSlash(root), func(p string, info fs.FileInfo, err error) error {
+		if err != nil || p == "." {
+			return nil
+		}
+		name := filepath.ToSlash(p)
+		if info.IsDir() {
+			if slices.Contains(defaultWatchSkip, info.Name()) && !w.inside(name) {
+				return filepath.SkipDir
+			}
+			if matchAnyGlob(w.ignore, name) || matchAnyGlob(w.ignore, name+"/") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if matchAnyGlob(w.ignore, name) || !matchAnyGlob(w.paths, name) {
+			return nil
+		}
+		files[name] = fileStamp{modTime: info.ModTime(), size: info.Size()}
+		return nil
+	})
+}
+
+// inside returns true if a watched glob starts inside the dir directory.
+func (w *watcher) inside(dir string) bool {
+	return slices.ContainsFunc(w.paths, func(glob string) bool {
+		return strings.HasPrefix(glob, dir+"/")
+	})
+}
+
+// changes returns the files that were added, removed or modified since the
+// last scan.
+func (w *watcher) changes() []string {
+	files := w.scan()
+	var changed []string
+	for name, stamp := range files {
+		if previous, ok := w.files[name]; !ok || previous != stamp {
+			changed = append(changed, name)
+		}
+	}
+	for name := range w.files {
+		if _, ok := files[name]; !ok {
+			changed = append(changed, name)
+		}
+	}
+	w.files = files
+	sort.Strings(changed)
+	return changed
+}
+
+// wait returns the changed files once no change happened during debounce. It
+// returns nil if ctx is done or stop is closed first.
+func (w *watcher) wait(ctx context.Context, debounce time.Duration, stop <-chan struct{}) []string {
+	var pending []string
+	var lastChange time.Time
+	ticker := time.NewTicker(watchPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-stop:
+			return nil
+		case now := <-ticker.C:
+			if changed := w.changes(); len(changed) != 0 {
+				pending = append(pending, changed...)
+				lastChange = now
+				continue
+			}
+			if len(pending) != 0 && now.Sub(lastChange) >= debounce {
+				return pending
+			}
+		}
+	}
+}
+
+// watch runs the command of the handle and runs it again each time watched
+// files change, killing the previous run if it is still alive. It returns
+// the error of the context of the invocation when it is done.
+func (d *Driver) watch(ref string, spec *config.WatchSpec, debounce time.Duration) error {
+	w := newWatcher(spec)
+	fmt.Fprintf(d.stderr(), "Watching %s for [%s]...\n", strings.Join(spec.Paths, ", "), ref)
+	for first := true; ; first = false {
+		run, err := d.startWatched(ref, first)
+		if err != nil {
+			if first {
+				return err
+			}
+			fmt.Fprintf(d.stderr(), "[%s] %s\n", ref, d.maskError(err))
+		}
+
+		changed := w.wait(d.inv.ctx, debounce, d.watchStop)
+		if run != nil {
+			run.stop()
+		}
+		if changed == nil {
+			return d.inv.ctx.Err()
+		}
+		fmt.Fprintf(d.stderr(), "Changed %s, re-running [%s]...\n", strings.Join(changed, ", "), ref)
+	}
+}
+
+// watchedRun is a run of a watched command.
+type watchedRun struct {
+	cancel  context.CancelFunc
+	done    chan struct{}
+	cleanup func()
+}
+
+// startWatched renders the command and starts it, with its hooks, without
+// waiting for it. The first run is confirmed if the handle requires it.
+func (d *Driver) startWatched(ref string, first bool) (*watchedRun, error) {
+	rendered, err := d.buildCmdArgs()
+	if err != nil {
+		return nil, err
+	}
+	cmd := d.newCmd(rendered)
+	if first {
+		if err := d.confirm(ref, cmd); err != nil {
+			if rendered.cleanup != nil {
+				rendered.cleanup()
+			}
+			return nil, err
+		}
+	}
+	if d.opts.debug {
+		fmt.Fprintf(d.stderr(), "Executing [%s] -> `%s`...\n", ref, d.mask(cmd.String()))
+	}
+	cmd.Stdin = d.stdin()
+	if rendered.stdin != nil {
+		cmd.Stdin = strings.NewReader(*rendered.stdin)
+	}
+	cmd.Stdout = d.opts.out
+	cmd.Stderr = d.stderr()
+
+	cmdSpec, _ := d.getCmdSpec()
+	hooks := d.hooksOf(cmdSpec)
+	ctx, cancel := context.WithCancel(d.inv.ctx)
+	run := &watchedRun{cancel: cancel, done: make(chan struct{}), cleanup: rendered.cleanup}
+	go func() {
+		defer close(run.done)
+		err := d.runWithHooks(ctx, ref, hooks, func() error { return d.runCmdContext(ctx, cmd) })
+		// report failures, but not the ones caused by a restart
+		if err != nil && ctx.Err() == nil {
+			fmt.Fprintf(d.stderr(), "%s\n", d.maskError(err))
+		}
+	}()
+	return run, nil
+}
+
+// stop kills the run if it is still alive and waits for it.
+func (r *watchedRun) stop() {
+	r.cancel()
+	<-r.done
+	r.finish()
+}
+
+func (r *watchedRun) finish() {
+	if r.cleanup != nil {
+		r.cleanup()
+	}
+}
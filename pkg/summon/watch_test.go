@@ -0,0 +1,271 @@
+package summon
+
+import (
+	"bytes"
+	"context"
+	"maps"
+	"os/exec"
+	"slices"
+	"sync"
+	"syscall"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/lithammer/dedent"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/davidovich/summon/internal/testutil"
+	"github.com/davidovich/summon/pkg/command"
+	"github.com/davidovich/summon/pkg/config"
+)
+
+func TestWatch(t *testing.T) {
+	defer testutil.ReplaceFs()()
+	defer func(interval time.Duration) { watchPollInterval = interval }(watchPollInterval)
+	watchPollInterval = 5 * time.Millisecond
+
+	watchConfig := dedent.Dedent(`
+		exec:
+		  handles:
+		    test:
+		      cmd: [sleep]
+		      args: ['10']
+		      watch:
+		        paths: ['src/**/*.go']
+		        ignore: ['src/gen/**']
+		        debounce: 10ms
+		`)
+	testFs := fstest.MapFS{}
+	testFs[config.ConfigFileName] = &fstest.MapFile{Data: []byte(watchConfig)}
+
+	require.NoError(t, afero.WriteFile(appFs, "src/a.go", []byte("package a"), 0o644))
+
+	var mu sync.Mutex
+	calls := 0
+	runs := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return calls
+	}
+	s, err := New(testFs, ExecCmd(func(c string, args ...string) *command.Cmd {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+		return command.New(c, args...)
+	}))
+	require.NoError(t, err)
+	_, err = s.ConstructCommandTree(&cobra.Command{Use: "root"}, false)
+	require.NoError(t, err)
+	s.watchStop = make(chan struct{})
+
+	stderr := &syncBuffer{}
+	done := make(chan error)
+	go func() {
+		done <- s.Run(Ref("test"), Err(stderr))
+	}()
+
+	assert.Eventually(t, func() bool { return runs() == 1 }, time.Second, time.Millisecond)
+
+	// ignored and unwatched files do not re-run the command
+	require.NoError(t, afero.WriteFile(appFs, "src/gen/gen.go", []byte("package gen"), 0o644))
+	require.NoError(t, afero.WriteFile(appFs, "src/a.txt", []byte("text"), 0o644))
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, 1, runs())
+
+	// the still running sleep is killed and the command re-run
+	require.NoError(t, afero.WriteFile(appFs, "src/b/b.go", []byte("package b"), 0o644))
+	assert.Eventually(t, func() bool { return runs() == 2 }, time.Second, time.Millisecond)
+
+	close(s.watchStop)
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("watch did not stop")
+	}
+	assert.Contains(t, stderr.String(), "Watching src/**/*.go for [test]...\nChanged src/b/b.go, re-running [test]...\n")
+}
+
+func TestWatchHooks(t *testing.T) {
+	defer testutil.ReplaceFs()()
+	defer func(interval time.Duration) { watchPollInterval = interval }(watchPollInterval)
+	watchPollInterval = 5 * time.Millisecond
+
+	watchConfig := dedent.Dedent(`
+		exec:
+		  handles:
+		    test:
+		      cmd: [echo]
+		      args: [run]
+		      before: [[echo, before]]
+		      after: [[echo, after]]
+		      watch:
+		        paths: ['src/*.go']
+		        debounce: 10ms
+		`)
+	testFs := fstest.MapFS{}
+	testFs[config.ConfigFileName] = &fstest.MapFile{Data: []byte(watchConfig)}
+
+	require.NoError(t, afero.WriteFile(appFs, "src/a.go", []byte("package a"), 0o644))
+
+	s, err := New(testFs)
+	require.NoError(t, err)
+	_, err = s.ConstructCommandTree(&cobra.Command{Use: "root"}, false)
+	require.NoError(t, err)
+	s.watchStop = make(chan struct{})
+
+	out := &syncBuffer{}
+	done := make(chan error)
+	go func() {
+		done <- s.Run(Ref("test"), Out(out), Err(&syncBuffer{}))
+	}()
+
+	// the hooks run around each watched run
+	assert.Eventually(t, func() bool { return out.String() == "before\nrun\nafter\n" }, time.Second, time.Millisecond)
+	require.NoError(t, afero.WriteFile(appFs, "src/b.go", []byte("package b"), 0o644))
+	assert.Eventually(t, func() bool { return out.String() == "before\nrun\nafter\nbefore\nrun\nafter\n" }, time.Second, time.Millisecond)
+
+	close(s.watchStop)
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("watch did not stop")
+	}
+}
+
+func TestWatchRunContext(t *testing.T) {
+	if _, err := exec.LookPath("sleep"); err != nil {
+		t.Skip("sleep is needed")
+	}
+	defer testutil.ReplaceFs()()
+
+	testFs := fstest.MapFS{}
+	testFs[config.ConfigFileName] = &fstest.MapFile{Data: []byte("exec: {handles: {sleep: {cmd: [sleep, '10'], watch: {paths: ['*.go']}}}}\n")}
+	s, err := New(testFs)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	done := make(chan error)
+	go func() {
+		_, err := s.RunContext(ctx, Ref("sleep"), Err(&syncBuffer{}))
+		done <- err
+	}()
+
+	// watching stops with the context
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	case <-time.After(2 * time.Second):
+		t.Fatal("watch did not stop with the context")
+	}
+}
+
+// syncBuffer is a bytes.Buffer safe for concurrent writes.
+type syncBuffer struct {
+	mu sync.Mutex
+	b  bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.b.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.b.String()
+}
+
+func TestWatchSpecCopiesConfig(t *testing.T) {
+	defer testutil.ReplaceFs()()
+	ignore := make([]string, 1, 2)
+	ignore[0] = "gen/**"
+	cmdSpec := &commandSpec{watch: &config.WatchSpec{Paths: []string{"**/*.go"}, Ignore: ignore}}
+
+	d := &Driver{}
+	d.opts.watchIgnore = []string{"tmp/**"}
+	spec, _, err := d.watchSpec(cmdSpec)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"gen/**", "tmp/**"}, spec.Ignore)
+
+	newWatcher(spec)
+	assert.Equal(t, []string{"gen/**", ""}, ignore[:2], "the config slice is not written")
+	assert.Equal(t, []string{"gen/**", "tmp/**"}, spec.Ignore[:2])
+}
+
+func TestWatcherSkipsDirs(t *testing.T) {
+	defer testutil.ReplaceFs()()
+	for _, f := range []string{"a.go", "node_modules/m/m.go", ".git/g.go", "vendor/v/v.go", "src/vendor/v.go"} {
+		require.NoError(t, afero.WriteFile(appFs, f, []byte("package a"), 0o644))
+	}
+
+	w := newWatcher(&config.WatchSpec{Paths: []string{"*.go"}})
+	assert.Equal(t, []string{"a.go"}, slices.Sorted(maps.Keys(w.files)))
+
+	// globs starting inside a skipped directory walk it
+	w = newWatcher(&config.WatchSpec{Paths: []string{"vendor/**/*.go", "src/vendor/*.go"}})
+	assert.Equal(t, []string{"src/vendor/v.go", "vendor/v/v.go"}, slices.Sorted(maps.Keys(w.files)))
+}
+
+func TestGlobRoots(t *testing.T) {
+	assert.Equal(t, []string{"cmd", "src"}, globRoots([]string{"src/**/*.go", "src/a/*.go", "cmd/main.go", "cmd/**"}))
+	assert.Equal(t, []string{"."}, globRoots([]string{"src/**/*.go", "*.md"}))
+	assert.Equal(t, []string{"."}, globRoots([]string{"**/*.go"}))
+	assert.Equal(t, []string{"a", "a-b"}, globRoots([]string{"a-b/*", "a/b/*", "a/*"}))
+}
+
+func TestForwardSignal(t *testing.T) {
+	if _, err := exec.LookPath("sleep"); err != nil {
+		t.Skip("sleep is needed")
+	}
+	testFs := fstest.MapFS{}
+	testFs[config.ConfigFileName] = &fstest.MapFile{Data: []byte("exec: {handles: {sleep: [sleep, '10']}}\n")}
+	s, err := New(testFs)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error)
+	go func() {
+		_, err := s.RunContext(ctx, Ref("sleep"))
+		done <- err
+	}()
+
+	// the command runs in its own process group, like a watched command
+	assert.Eventually(t, func() bool {
+		running.mu.Lock()
+		defer running.mu.Unlock()
+		return len(running.cmds) == 1
+	}, time.Second, time.Millisecond)
+	ForwardSignal(syscall.SIGTERM)
+	select {
+	case err := <-done:
+		assert.Error(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the signal was not forwarded")
+	}
+}
+
+func TestWatchFlagOverridesConfig(t *testing.T) {
+	s, err := New(fstest.MapFS{}, Watch("*.txt"), WatchIgnore("tmp/**"))
+	require.NoError(t, err)
+
+	spec, debounce, err := s.watchSpec(&commandSpec{
+		watch: &config.WatchSpec{Paths: []string{"*.go"}, Ignore: []string{"vendor/**"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"*.txt"}, spec.Paths)
+	assert.Equal(t, []string{"vendor/**", "tmp/**"}, spec.Ignore)
+	assert.Equal(t, defaultDebounce, debounce)
+
+	_, _, err = s.watchSpec(&commandSpec{watch: &config.WatchSpec{Paths: []string{"*.go"}, Debounce: "soon"}})
+	assert.ErrorContains(t, err, "invalid watch debounce")
+}
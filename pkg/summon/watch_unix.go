@@ -0,0 +1,40 @@
+//go:build !windows
+
+package summon
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// startGroup makes the command lead its own process group so that it can be
+// killed with its children. The group is in the background of the terminal,
+// where reading it would stop the command, so the command does not read it.
+func startGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+	if f, ok := cmd.Stdin.(*os.File); ok && isTerminal(f) {
+		cmd.Stdin = nil
+	}
+}
+
+// killGroup kills the process group of a command started with startGroup.
+func killGroup(cmd *exec.Cmd) error {
+	if cmd.Process == nil {
+		return nil
+	}
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
+
+// signalGroup sends sig to the process group of a command started with
+// startGroup.
+func signalGroup(cmd *exec.Cmd, sig os.Signal) error {
+	s, ok := sig.(syscall.Signal)
+	if !ok || cmd.Process == nil {
+		return nil
+	}
+	return syscall.Kill(-cmd.Process.Pid, s)
+}
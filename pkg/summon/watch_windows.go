@@ -0,0 +1,23 @@
+package summon
+
+import (
+	"os"
+	"os/exec"
+)
+
+// startGroup is a no-op on windows.
+func startGroup(cmd *exec.Cmd) {}
+
+// killGroup kills the command process.
+func killGroup(cmd *exec.Cmd) error {
+	if cmd.Process == nil {
+		return nil
+	}
+	return cmd.Process.Kill()
+}
+
+// signalGroup kills the command process, as signals cannot be sent on
+// windows.
+func signalGroup(cmd *exec.Cmd, _ os.Signal) error {
+	return killGroup(cmd)
+}